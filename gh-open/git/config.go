@@ -0,0 +1,479 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxIncludeDepth is the maximum nesting depth of included config files
+const maxIncludeDepth = 10
+
+// Config contains the variables of a git config file and its included files
+type Config struct {
+	entries []configEntry
+}
+
+type configEntry struct {
+	Key   string
+	Value string
+}
+
+type configParser struct {
+	config   *Config
+	depth    int
+	fileName string
+	gitDir   string
+	key      string
+	section  string
+	value    *valueParser
+}
+
+type valueParser struct {
+	builder strings.Builder
+	quoted  bool
+	spaces  int
+}
+
+var (
+	configNameRegExp            = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*`)
+	continuedLineError          = errors.New("Unexpected end of file after a continuation line")
+	invalidEscapeError          = errors.New("Invalid escape sequence")
+	invalidSectionError         = errors.New("Invalid section header")
+	invalidVariableError        = errors.New("Invalid variable name")
+	missingSeparatorError       = errors.New("Missing \"=\" after variable name")
+	tooManyIncludesError        = errors.New("Too many nested includes")
+	unclosedQuoteError          = errors.New("Unclosed quote")
+	unclosedSectionError        = errors.New("Unclosed section header")
+	variableOutsideSectionError = errors.New("Variable outside of a section")
+)
+
+// ParseConfigFile parses the git config file. gitDir is used to evaluate
+// `includeIf "gitdir:..."` sections.
+func ParseConfigFile(fileName string, gitDir string) (*Config, error) {
+	config := &Config{}
+
+	if parseError := config.parseFile(fileName, gitDir, 0); parseError != nil {
+		return nil, parseError
+	}
+
+	return config, nil
+}
+
+// Get returns the last value of the key (e.g. "remote.origin.url")
+func (config *Config) Get(key string) (string, bool) {
+	values := config.GetAll(key)
+
+	if len(values) == 0 {
+		return "", false
+	}
+
+	return values[len(values)-1], true
+}
+
+// GetAll returns all values of the key
+func (config *Config) GetAll(key string) []string {
+	var values []string
+	normalizedKey := normalizeKey(key)
+
+	for _, entry := range config.entries {
+		if entry.Key == normalizedKey {
+			values = append(values, entry.Value)
+		}
+	}
+
+	return values
+}
+
+// Subsections returns the names of all subsections of the section (e.g. the
+// remote names for "remote")
+func (config *Config) Subsections(section string) []string {
+	var subsections []string
+	seen := make(map[string]bool)
+	prefix := strings.ToLower(section) + "."
+
+	for _, entry := range config.entries {
+		if !strings.HasPrefix(entry.Key, prefix) {
+			continue
+		}
+
+		lastDot := strings.LastIndex(entry.Key, ".")
+		if lastDot < len(prefix) {
+			continue
+		}
+
+		subsection := entry.Key[len(prefix):lastDot]
+		if seen[subsection] == false {
+			seen[subsection] = true
+			subsections = append(subsections, subsection)
+		}
+	}
+
+	return subsections
+}
+
+// normalizeKey lowercases the section and the variable name of the key, but
+// not the subsection
+func normalizeKey(key string) string {
+	firstDot := strings.Index(key, ".")
+	lastDot := strings.LastIndex(key, ".")
+
+	if firstDot == -1 {
+		return strings.ToLower(key)
+	}
+
+	return strings.ToLower(key[:firstDot]) + key[firstDot:lastDot] + strings.ToLower(key[lastDot:])
+}
+
+func (config *Config) parseFile(fileName string, gitDir string, depth int) error {
+	if depth > maxIncludeDepth {
+		return tooManyIncludesError
+	}
+
+	content, readError := ioutil.ReadFile(fileName)
+
+	if readError != nil {
+		return readError
+	}
+
+	parser := &configParser{
+		config:   config,
+		depth:    depth,
+		fileName: fileName,
+		gitDir:   gitDir,
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+
+	for index, line := range lines {
+		if parseError := parser.parseLine(line); parseError != nil {
+			return fmt.Errorf("Could not parse git config file \"%s\" (line %d): %w", fileName, index+1, parseError)
+		}
+	}
+
+	if parser.value != nil {
+		return fmt.Errorf("Could not parse git config file \"%s\": %w", fileName, continuedLineError)
+	}
+
+	return nil
+}
+
+func (parser *configParser) parseLine(line string) error {
+	if parser.value != nil {
+		return parser.continueValue(line)
+	}
+
+	line = strings.TrimLeft(line, " \t")
+
+	if line == "" || line[0] == '#' || line[0] == ';' {
+		return nil
+	}
+
+	if line[0] == '[' {
+		rest, sectionError := parser.parseSection(line)
+		if sectionError != nil {
+			return sectionError
+		}
+		return parser.parseLine(rest)
+	}
+
+	return parser.parseVariable(line)
+}
+
+// parseSection parses a section header ([section], [section "subsection"] or
+// the deprecated [section.subsection]) and returns the rest of the line
+func (parser *configParser) parseSection(line string) (string, error) {
+	closingIndex := -1
+	quoted := false
+
+	for index := 1; index < len(line); index++ {
+		if line[index] == '\\' && quoted {
+			index++
+		} else if line[index] == '"' {
+			quoted = !quoted
+		} else if line[index] == ']' && !quoted {
+			closingIndex = index
+			break
+		}
+	}
+
+	if closingIndex == -1 {
+		return "", unclosedSectionError
+	}
+
+	header := line[1:closingIndex]
+	rest := line[closingIndex+1:]
+
+	if spaceIndex := strings.IndexAny(header, " \t"); spaceIndex != -1 {
+		name := header[:spaceIndex]
+		subsection := strings.TrimSpace(header[spaceIndex:])
+
+		if !isSectionName(name) || len(subsection) < 2 || subsection[0] != '"' || subsection[len(subsection)-1] != '"' {
+			return "", invalidSectionError
+		}
+
+		unquotedSubsection := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(subsection[1 : len(subsection)-1])
+		parser.section = strings.ToLower(name) + "." + unquotedSubsection
+		return rest, nil
+	}
+
+	if !isSectionName(strings.ReplaceAll(header, ".", "")) {
+		return "", invalidSectionError
+	}
+
+	parser.section = strings.ToLower(header)
+	return rest, nil
+}
+
+func isSectionName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, character := range name {
+		if !(character >= 'a' && character <= 'z' || character >= 'A' && character <= 'Z' || character >= '0' && character <= '9' || character == '-') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (parser *configParser) parseVariable(line string) error {
+	if parser.section == "" {
+		return variableOutsideSectionError
+	}
+
+	name := configNameRegExp.FindString(line)
+	if name == "" {
+		return invalidVariableError
+	}
+
+	parser.key = parser.section + "." + strings.ToLower(name)
+	rest := strings.TrimLeft(line[len(name):], " \t")
+
+	if rest == "" || rest[0] == '#' || rest[0] == ';' {
+		return parser.addEntry(parser.key, "true")
+	}
+
+	if rest[0] != '=' {
+		return missingSeparatorError
+	}
+
+	parser.value = &valueParser{}
+	return parser.continueValue(rest[1:])
+}
+
+func (parser *configParser) continueValue(line string) error {
+	done, valueError := parser.value.parse(line)
+
+	if valueError != nil {
+		return valueError
+	}
+
+	if done {
+		value := parser.value.builder.String()
+		parser.value = nil
+		return parser.addEntry(parser.key, value)
+	}
+
+	return nil
+}
+
+// parse adds the line to the value and reports whether the value is complete
+// (i.e. the line does not end with a backslash)
+func (value *valueParser) parse(line string) (bool, error) {
+	for index := 0; index < len(line); index++ {
+		character := line[index]
+
+		switch {
+		case character == ' ' || character == '\t':
+			if value.quoted {
+				value.builder.WriteByte(character)
+			} else if value.builder.Len() > 0 {
+				value.spaces++
+			}
+			continue
+		case (character == '#' || character == ';') && !value.quoted:
+			return value.finish()
+		}
+
+		for ; value.spaces > 0; value.spaces-- {
+			value.builder.WriteByte(' ')
+		}
+
+		switch character {
+		case '"':
+			value.quoted = !value.quoted
+		case '\\':
+			if index == len(line)-1 {
+				return false, nil
+			}
+
+			index++
+			switch line[index] {
+			case 'n':
+				value.builder.WriteByte('\n')
+			case 't':
+				value.builder.WriteByte('\t')
+			case 'b':
+				value.builder.WriteByte('\b')
+			case '"', '\\':
+				value.builder.WriteByte(line[index])
+			default:
+				return false, invalidEscapeError
+			}
+		default:
+			value.builder.WriteByte(character)
+		}
+	}
+
+	return value.finish()
+}
+
+func (value *valueParser) finish() (bool, error) {
+	if value.quoted {
+		return false, unclosedQuoteError
+	}
+
+	return true, nil
+}
+
+// addEntry adds the variable to the config and follows includes
+func (parser *configParser) addEntry(key string, value string) error {
+	parser.config.entries = append(parser.config.entries, configEntry{Key: key, Value: value})
+
+	if !strings.HasSuffix(key, ".path") {
+		return nil
+	}
+
+	section := strings.TrimSuffix(key, ".path")
+
+	if section == "include" || (strings.HasPrefix(section, "includeif.") && parser.matchesCondition(section[len("includeif."):])) {
+		return parser.include(value)
+	}
+
+	return nil
+}
+
+// include parses the included file. Relative paths are relative to the
+// including file, missing files are ignored like git does.
+func (parser *configParser) include(path string) error {
+	includePath := resolvePath(filepath.Dir(parser.fileName), expandHome(path))
+
+	if _, statError := os.Stat(includePath); os.IsNotExist(statError) {
+		return nil
+	}
+
+	return parser.config.parseFile(includePath, parser.gitDir, parser.depth+1)
+}
+
+// matchesCondition evaluates an includeIf condition. Only "gitdir:" and
+// "gitdir/i:" are supported, other conditions never match.
+func (parser *configParser) matchesCondition(condition string) bool {
+	var (
+		caseInsensitive bool
+		pattern         string
+	)
+
+	switch {
+	case strings.HasPrefix(condition, "gitdir:"):
+		pattern = strings.TrimPrefix(condition, "gitdir:")
+	case strings.HasPrefix(condition, "gitdir/i:"):
+		pattern = strings.TrimPrefix(condition, "gitdir/i:")
+		caseInsensitive = true
+	default:
+		return false
+	}
+
+	if parser.gitDir == "" || pattern == "" {
+		return false
+	}
+
+	pattern = expandHome(pattern)
+
+	if strings.HasPrefix(pattern, "./") {
+		pattern = filepath.Join(filepath.Dir(parser.fileName), pattern[2:])
+		if strings.HasSuffix(condition, "/") {
+			pattern += "/"
+		}
+	} else if !filepath.IsAbs(pattern) {
+		pattern = "**/" + pattern
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	gitDir := filepath.ToSlash(filepath.Clean(parser.gitDir))
+	pattern = filepath.ToSlash(pattern)
+
+	if caseInsensitive == true {
+		gitDir = strings.ToLower(gitDir)
+		pattern = strings.ToLower(pattern)
+	}
+
+	return globRegExp(pattern).MatchString(gitDir)
+}
+
+// globRegExp converts a wildcard pattern with "*", "**" and "?" to a regular
+// expression
+func globRegExp(pattern string) *regexp.Regexp {
+	var builder strings.Builder
+	builder.WriteString("^")
+
+	for index := 0; index < len(pattern); index++ {
+		switch {
+		case strings.HasPrefix(pattern[index:], "**/"):
+			builder.WriteString("(?:.*/)?")
+			index += 2
+		case strings.HasPrefix(pattern[index:], "**"):
+			builder.WriteString(".*")
+			index++
+		case pattern[index] == '*':
+			builder.WriteString("[^/]*")
+		case pattern[index] == '?':
+			builder.WriteString("[^/]")
+		default:
+			builder.WriteString(regexp.QuoteMeta(pattern[index : index+1]))
+		}
+	}
+
+	builder.WriteString("$")
+	return regexp.MustCompile(builder.String())
+}
+
+// expandHome replaces a leading "~/" with the home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	homeDir, homeError := os.UserHomeDir()
+	if homeError != nil {
+		return path
+	}
+
+	return filepath.Join(homeDir, path[2:])
+}
@@ -0,0 +1,239 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, fileName string, content string) {
+	t.Helper()
+
+	if mkdirError := os.MkdirAll(filepath.Dir(fileName), 0755); mkdirError != nil {
+		t.Fatal(mkdirError)
+	}
+
+	if writeError := os.WriteFile(fileName, []byte(content), 0644); writeError != nil {
+		t.Fatal(writeError)
+	}
+}
+
+func parseTestConfig(t *testing.T, content string) *Config {
+	t.Helper()
+
+	configFile := filepath.Join(t.TempDir(), "config")
+	writeTestFile(t, configFile, content)
+
+	config, parseError := ParseConfigFile(configFile, "")
+	if parseError != nil {
+		t.Fatal(parseError)
+	}
+
+	return config
+}
+
+func TestConfigValues(t *testing.T) {
+	config := parseTestConfig(t, strings.Join([]string{
+		"# comment",
+		"[Core]",
+		"\tBare = false ; comment",
+		"\tfilemode",
+		`[remote "Origin"]`,
+		`	url = "https://github.com/user/repo.git" # trailing comment`,
+		"	pushurl = https://github.com/push/only.git",
+		"[remote.Legacy]",
+		"	url = git@github.com:legacy/repo.git",
+		"[alias]",
+		`	quoted = "a ; b # c"`,
+		`	escaped = tab\there \"quote\" back\\slash`,
+		"	spaces =   inner   spaces   ",
+		`	continued = first \`,
+		"	second",
+		`	quotedContinued = "one\`,
+		`two"`,
+		`[section "sub \"quoted\""]`,
+		"	key = value",
+	}, "\n"))
+
+	testCases := []struct {
+		key   string
+		value string
+	}{
+		{"core.bare", "false"},
+		{"CORE.BARE", "false"},
+		{"core.filemode", "true"},
+		{"remote.Origin.url", "https://github.com/user/repo.git"},
+		{"remote.Origin.pushurl", "https://github.com/push/only.git"},
+		{"remote.legacy.url", "git@github.com:legacy/repo.git"},
+		{"alias.quoted", "a ; b # c"},
+		{"alias.escaped", "tab\there \"quote\" back\\slash"},
+		{"alias.spaces", "inner   spaces"},
+		{"alias.continued", "first  second"},
+		{"alias.quotedcontinued", "onetwo"},
+		{`section.sub "quoted".key`, "value"},
+	}
+
+	for _, testCase := range testCases {
+		value, found := config.Get(testCase.key)
+		if !found || value != testCase.value {
+			t.Errorf("Get(%q) = %q, %v, want %q", testCase.key, value, found, testCase.value)
+		}
+	}
+
+	if _, found := config.Get("remote.origin.url"); found {
+		t.Error("subsection names must be case sensitive")
+	}
+
+	if remotes := strings.Join(config.Subsections("remote"), ","); remotes != "Origin,legacy" {
+		t.Errorf("Subsections(\"remote\") = %q", remotes)
+	}
+}
+
+func TestConfigMultipleValues(t *testing.T) {
+	config := parseTestConfig(t, "[remote \"origin\"]\n\tfetch = a\n\tfetch = b\n")
+
+	if values := strings.Join(config.GetAll("remote.origin.fetch"), ","); values != "a,b" {
+		t.Errorf("GetAll() = %q, want \"a,b\"", values)
+	}
+
+	if value, _ := config.Get("remote.origin.fetch"); value != "b" {
+		t.Errorf("Get() = %q, want the last value \"b\"", value)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	testCases := []string{
+		"key = value",
+		"[core",
+		"[core]\n\turl = \"unclosed",
+		"[core]\n\turl = bad\\escape",
+		"[core]\n\t1key = value",
+		"[core]\n\tkey value",
+		"[core]\n\tkey = continued\\",
+		"[remote origin]",
+	}
+
+	for _, content := range testCases {
+		configFile := filepath.Join(t.TempDir(), "config")
+		writeTestFile(t, configFile, content)
+
+		if _, parseError := ParseConfigFile(configFile, ""); parseError == nil {
+			t.Errorf("ParseConfigFile(%q) returned no error", content)
+		}
+	}
+}
+
+func TestConfigIncludes(t *testing.T) {
+	baseDir := t.TempDir()
+	gitDir := filepath.Join(baseDir, "work", "project", ".git")
+	configFile := filepath.Join(gitDir, "config")
+
+	writeTestFile(t, configFile, strings.Join([]string{
+		"[remote \"origin\"]",
+		"	url = https://github.com/user/repo.git",
+		"[include]",
+		"	path = ../../relative.cfg",
+		"	path = missing.cfg",
+		"[includeIf \"gitdir:" + filepath.ToSlash(filepath.Join(baseDir, "work")) + "/\"]",
+		"	path = " + filepath.Join(baseDir, "matching.cfg"),
+		"[includeIf \"gitdir:project/.git\"]",
+		"	path = " + filepath.Join(baseDir, "relative-pattern.cfg"),
+		"[includeIf \"gitdir/i:" + strings.ToUpper(filepath.ToSlash(baseDir)) + "/WORK/**\"]",
+		"	path = " + filepath.Join(baseDir, "case-insensitive.cfg"),
+		"[includeIf \"gitdir:/elsewhere/\"]",
+		"	path = " + filepath.Join(baseDir, "other.cfg"),
+		"[includeIf \"onbranch:main\"]",
+		"	path = " + filepath.Join(baseDir, "other.cfg"),
+	}, "\n"))
+
+	writeTestFile(t, filepath.Join(baseDir, "work", "relative.cfg"), "[remote \"fork\"]\n\turl = https://github.com/me/fork\n")
+	writeTestFile(t, filepath.Join(baseDir, "matching.cfg"), "[remote \"origin\"]\n\turl = https://github.com/override/repo\n")
+	writeTestFile(t, filepath.Join(baseDir, "relative-pattern.cfg"), "[user]\n\tname = relative\n")
+	writeTestFile(t, filepath.Join(baseDir, "case-insensitive.cfg"), "[user]\n\temail = case@insensitive\n")
+	writeTestFile(t, filepath.Join(baseDir, "other.cfg"), "[remote \"other\"]\n\turl = https://github.com/other/repo\n")
+
+	config, parseError := ParseConfigFile(configFile, gitDir)
+	if parseError != nil {
+		t.Fatal(parseError)
+	}
+
+	testCases := []struct {
+		key   string
+		value string
+	}{
+		{"remote.fork.url", "https://github.com/me/fork"},
+		{"remote.origin.url", "https://github.com/override/repo"},
+		{"user.name", "relative"},
+		{"user.email", "case@insensitive"},
+	}
+
+	for _, testCase := range testCases {
+		if value, _ := config.Get(testCase.key); value != testCase.value {
+			t.Errorf("Get(%q) = %q, want %q", testCase.key, value, testCase.value)
+		}
+	}
+
+	if _, found := config.Get("remote.other.url"); found {
+		t.Error("includeIf with a non-matching condition was included")
+	}
+}
+
+func TestConfigRecursiveInclude(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	writeTestFile(t, configFile, "[include]\n\tpath = config\n")
+
+	if _, parseError := ParseConfigFile(configFile, ""); parseError == nil {
+		t.Error("ParseConfigFile() returned no error for a recursive include")
+	}
+}
+
+func TestSelectRemote(t *testing.T) {
+	config := parseTestConfig(t, strings.Join([]string{
+		"[branch \"feature\"]",
+		"	remote = upstream",
+		"	merge = refs/heads/jdoe/feature",
+		"[branch \"local\"]",
+		"	remote = .",
+		"	merge = refs/heads/main",
+	}, "\n"))
+
+	gitClient := New(0, false)
+
+	testCases := []struct {
+		branch string
+		flag   string
+		remote string
+	}{
+		{"feature", "", "upstream"},
+		{"feature", "fork", "fork"},
+		{"local", "", "origin"},
+		{"main", "", "origin"},
+		{"", "", "origin"},
+	}
+
+	for _, testCase := range testCases {
+		gitClient.Remote = testCase.flag
+
+		if remote := gitClient.SelectRemote(config, testCase.branch); remote != testCase.remote {
+			t.Errorf("SelectRemote(%q) with --remote %q = %q, want %q", testCase.branch, testCase.flag, remote, testCase.remote)
+		}
+	}
+}
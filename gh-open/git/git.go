@@ -18,9 +18,11 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package git
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -34,19 +36,21 @@ import (
 type Client struct {
 	DebugMode bool
 	Logger    *simplelogger.SimpleLogger
+	Remote    string
 	Timeout   int
 }
 
 const (
 	fullURLRegex     = `(?i)^(?:.+?://(?:.+@)?|(?:.+@)?)(.+?)[:/](.+?)(?:.git)?/?$`
 	gitBranchRegex   = `(?mi)ref: refs/heads/(.*)$`
+	gitDirRegex      = `(?m)^gitdir: (.*)$`
 	pullRequestRegex = `(?i)github\.com/([^\/]+)/([^/]+)/tree/(.*)`
-	rawURLRegex      = `(?mi).*url = (.*)`
 )
 
 // New returns a new instance of Client
 func New(timeout int, debugMode bool) *Client {
-	logger := simplelogger.New("gh-open/gitclient", debugMode, true)
+	logger := simplelogger.NewChild("gh-open", "gitclient", debugMode)
+
 	gitClient := &Client{
 		DebugMode: debugMode,
 		Logger:    logger,
@@ -75,77 +79,198 @@ func (gitClient *Client) readFile(fileName string) (*[]byte, error) {
 }
 
 // ParseBranch takes a git directory and returns it's current branch.
-func (gitClient *Client) ParseBranch(gitDir string) ([]byte, error) {
-	gitHeadFile, absError := filepath.Abs(filepath.Join(gitDir, "HEAD"))
+func (gitClient *Client) ParseBranch(ctx context.Context, gitDir string) ([]byte, error) {
+	head, headError := gitClient.ParseHead(ctx, gitDir)
+
+	if headError != nil {
+		return nil, headError
+	}
+
+	if head.Branch == "" {
+		return nil, errors.New("No branch found in git HEAD file")
+	}
+
+	return []byte(head.Branch), nil
+}
+
+// ParseConfig takes a git directory and parses the config of its repository
+// (for a worktree the config of the main repository).
+func (gitClient *Client) ParseConfig(ctx context.Context, gitDir string) (*Config, error) {
+	commonDir, commonDirError := gitClient.FindCommonDir(ctx, gitDir)
+
+	if commonDirError != nil {
+		return nil, commonDirError
+	}
+
+	gitConfigFile, absError := filepath.Abs(filepath.Join(commonDir, "config"))
 
 	if absError != nil {
 		return nil, absError
 	}
 
-	if _, statError := os.Stat(gitHeadFile); os.IsNotExist(statError) {
-		return nil, fmt.Errorf("Could not find git HEAD file in \"%s\"", gitDir)
+	gitClient.Logger.LogfContext(ctx, "Found git config file \"%s\"", gitConfigFile)
+
+	if _, statError := os.Stat(gitConfigFile); os.IsNotExist(statError) {
+		return nil, fmt.Errorf("Could not find git config file in \"%s\"", commonDir)
 	}
 
-	gitHead, readFileError := gitClient.readFile(gitHeadFile)
+	return ParseConfigFile(gitConfigFile, gitDir)
+}
 
-	if readFileError != nil {
-		return nil, readFileError
+// SelectRemote returns the name of the remote to use: the client's Remote if
+// set, then the remote configured for the branch (branch.<name>.remote), then
+// "origin".
+func (gitClient *Client) SelectRemote(config *Config, branch string) string {
+	if gitClient.Remote != "" {
+		return gitClient.Remote
 	}
 
-	gitClient.Logger.Logf("Read git head file: \"%s\"", strings.TrimSpace(string(*gitHead)))
+	if branch != "" {
+		if branchRemote, found := config.Get("branch." + branch + ".remote"); found && branchRemote != "." {
+			return branchRemote
+		}
+	}
 
-	gitBranchRegExp := regexp.MustCompile(gitBranchRegex)
-	branchMatches := gitBranchRegExp.FindSubmatch(*gitHead)
+	return "origin"
+}
 
-	if len(branchMatches) != 2 {
-		return nil, errors.New("No branch found in git HEAD file")
+// UpstreamBranch returns the name of the remote branch the branch tracks
+// (branch.<name>.merge) or the branch itself if it has no upstream on the
+// selected remote.
+func (gitClient *Client) UpstreamBranch(ctx context.Context, config *Config, branch string) string {
+	branchRemote, hasRemote := config.Get("branch." + branch + ".remote")
+	mergeRef, hasMerge := config.Get("branch." + branch + ".merge")
+
+	if !hasRemote || !hasMerge || branchRemote == "." || branchRemote != gitClient.SelectRemote(config, branch) {
+		return branch
+	}
+
+	upstreamBranch := strings.TrimPrefix(mergeRef, "refs/heads/")
+	if upstreamBranch != branch {
+		gitClient.Logger.LogfContext(ctx, "Branch \"%s\" tracks \"%s\" on remote \"%s\"", branch, upstreamBranch, branchRemote)
 	}
 
-	return branchMatches[1], nil
+	return upstreamBranch
 }
 
-// ParseRawURL takes a git directory and returns it's raw URL.
-func (gitClient *Client) ParseRawURL(gitDir string) ([]byte, error) {
-	gitConfigFile, absError := filepath.Abs(filepath.Join(gitDir, "config"))
+// ParseRawURL takes a git directory and the current branch and returns the raw
+// URL of the selected remote.
+func (gitClient *Client) ParseRawURL(ctx context.Context, gitDir string, branch string) ([]byte, error) {
+	gitConfig, configError := gitClient.ParseConfig(ctx, gitDir)
 
-	if absError != nil {
-		return nil, absError
+	if configError != nil {
+		return nil, configError
 	}
 
-	gitClient.Logger.Logf("Found git config file \"%s\"", gitConfigFile)
+	return gitClient.remoteURL(ctx, gitConfig, branch)
+}
 
-	if _, statError := os.Stat(gitConfigFile); os.IsNotExist(statError) {
-		return nil, fmt.Errorf("Could not find git config file in \"%s\"", gitDir)
+func (gitClient *Client) remoteURL(ctx context.Context, config *Config, branch string) ([]byte, error) {
+	remote := gitClient.SelectRemote(config, branch)
+	gitClient.Logger.LogfContext(ctx, "Using remote \"%s\"", remote)
+
+	rawURL, found := config.Get("remote." + remote + ".url")
+
+	if !found || rawURL == "" {
+		return nil, fmt.Errorf("No URL found for remote \"%s\" in git config file", remote)
+	}
+
+	return []byte(rawURL), nil
+}
+
+// FindGitDir takes a directory and returns it's next git directory. If the
+// ".git" entry is a file (e.g. in a worktree or a submodule), the "gitdir:"
+// reference in it is followed.
+func (gitClient *Client) FindGitDir(ctx context.Context, mainDir string) (string, error) {
+	foundDir, walkError := gitClient.findUp(ctx, mainDir, ".git")
+
+	if walkError != nil {
+		return "", walkError
+	}
+
+	return gitClient.resolveGitDir(ctx, foundDir)
+}
+
+// FindCommonDir takes a git directory and returns the directory which contains
+// the config and the refs shared by all worktrees. For a worktree this is the
+// git directory of the main repository, otherwise the git directory itself.
+func (gitClient *Client) FindCommonDir(ctx context.Context, gitDir string) (string, error) {
+	commonDirFile := filepath.Join(gitDir, "commondir")
+
+	if _, statError := os.Stat(commonDirFile); os.IsNotExist(statError) {
+		return gitDir, nil
+	}
+
+	commonDir, readFileError := gitClient.readFile(commonDirFile)
+
+	if readFileError != nil {
+		return "", readFileError
+	}
+
+	resolvedDir := resolvePath(gitDir, strings.TrimSpace(string(*commonDir)))
+	gitClient.Logger.LogfContext(ctx, "Found common dir \"%s\"", resolvedDir)
+
+	return resolvedDir, nil
+}
+
+// resolveGitDir returns the git directory for a ".git" entry, which is either
+// the directory itself or a file containing "gitdir: <path>"
+func (gitClient *Client) resolveGitDir(ctx context.Context, dotGit string) (string, error) {
+	fileInfo, statError := os.Stat(dotGit)
+
+	if statError != nil {
+		return "", statError
+	}
+
+	if fileInfo.IsDir() {
+		return dotGit, nil
 	}
 
-	gitConfig, readFileError := gitClient.readFile(gitConfigFile)
+	content, readFileError := gitClient.readFile(dotGit)
 
 	if readFileError != nil {
-		return nil, readFileError
+		return "", readFileError
+	}
+
+	gitDirRegExp := regexp.MustCompile(gitDirRegex)
+	gitDirMatches := gitDirRegExp.FindSubmatch(*content)
+
+	if len(gitDirMatches) != 2 {
+		return "", fmt.Errorf("No gitdir found in \"%s\"", dotGit)
 	}
 
-	rawURLRegExp := regexp.MustCompile(rawURLRegex)
-	rawURLMatches := rawURLRegExp.FindSubmatch(*gitConfig)
+	gitDir := resolvePath(filepath.Dir(dotGit), strings.TrimSpace(string(gitDirMatches[1])))
+	gitClient.Logger.LogfContext(ctx, "Following gitdir file \"%s\" to \"%s\"", dotGit, gitDir)
+
+	if _, statError := os.Stat(gitDir); os.IsNotExist(statError) {
+		return "", fmt.Errorf("Could not find the git directory \"%s\" referenced in \"%s\"", gitDir, dotGit)
+	}
+
+	return gitDir, nil
+}
 
-	if len(rawURLMatches) != 2 {
-		return nil, errors.New("No raw URL found in git config file")
+// resolvePath returns the path relative to the base directory if it is not absolute
+func resolvePath(baseDir string, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
 	}
 
-	return rawURLMatches[1], nil
+	return filepath.Join(baseDir, path)
 }
 
-// FindGitDir takes a directory and returns it's next git directory.
-func (gitClient *Client) FindGitDir(mainDir string) (string, error) {
-	foundDir, walkError := gitClient.findUp(mainDir, ".git")
+// FindWorkTree takes a directory and returns the root directory of the checkout
+// it is in (i.e. the directory containing the ".git" entry).
+func (gitClient *Client) FindWorkTree(ctx context.Context, mainDir string) (string, error) {
+	foundDir, walkError := gitClient.findUp(ctx, mainDir, ".git")
 
 	if walkError != nil {
 		return "", walkError
 	}
 
-	return foundDir, nil
+	return filepath.Dir(foundDir), nil
 }
 
-func (gitClient *Client) findUp(initialDir string, targetDir string) (string, error) {
+func (gitClient *Client) findUp(ctx context.Context, initialDir string, targetDir string) (string, error) {
 	var mainDir = &initialDir
 
 	if _, statError := os.Stat(initialDir); os.IsNotExist(statError) {
@@ -154,7 +279,7 @@ func (gitClient *Client) findUp(initialDir string, targetDir string) (string, er
 
 	for {
 		var joinedPath = filepath.Join(*mainDir, targetDir)
-		gitClient.Logger.Logf("Searching for git dir in \"%s\"", *mainDir)
+		gitClient.Logger.LogfContext(ctx, "Searching for git dir in \"%s\"", *mainDir)
 
 		if _, statError := os.Stat(joinedPath); os.IsNotExist(statError) {
 			absoluteDir, absError := filepath.Abs(filepath.Join(*mainDir, "../"))
@@ -176,48 +301,84 @@ func (gitClient *Client) findUp(initialDir string, targetDir string) (string, er
 	}
 }
 
-// GetFullURL takes a directory and (given it's inside a git repository) returns the repository's full URL.
-func (gitClient *Client) GetFullURL(mainDir string) (string, error) {
-	gitDir, findGitDirError := gitClient.FindGitDir(mainDir)
+// OpenRepository takes a directory and (given it's inside a git repository)
+// returns the repository with its GitHub URL and checked out branch.
+func (gitClient *Client) OpenRepository(ctx context.Context, mainDir string) (*Repository, error) {
+	workTree, workTreeError := gitClient.FindWorkTree(ctx, mainDir)
 
-	if findGitDirError != nil {
-		return "", findGitDirError
+	if workTreeError != nil {
+		return nil, workTreeError
 	}
 
-	gitClient.Logger.Logf("Found git dir \"%s\"", string(gitDir))
+	gitDir, gitDirError := gitClient.resolveGitDir(ctx, filepath.Join(workTree, ".git"))
 
-	gitRawURL, gitRawURLError := gitClient.ParseRawURL(gitDir)
+	if gitDirError != nil {
+		return nil, gitDirError
+	}
 
-	if gitRawURLError != nil {
-		return "", gitRawURLError
+	gitClient.Logger.LogfContext(ctx, "Found git dir \"%s\"", string(gitDir))
+
+	gitHead, gitHeadError := gitClient.ParseHead(ctx, gitDir)
+
+	if gitHeadError != nil {
+		return nil, gitHeadError
 	}
 
-	gitClient.Logger.Logf("Found raw URL \"%s\"", string(gitRawURL))
+	gitConfig, gitConfigError := gitClient.ParseConfig(ctx, gitDir)
 
-	gitBranch, gitBranchError := gitClient.ParseBranch(gitDir)
+	if gitConfigError != nil {
+		return nil, gitConfigError
+	}
+
+	gitRawURL, gitRawURLError := gitClient.remoteURL(ctx, gitConfig, gitHead.Branch)
 
-	if gitBranchError != nil {
-		return "", gitBranchError
+	if gitRawURLError != nil {
+		return nil, gitRawURLError
 	}
 
+	gitClient.Logger.LogfContext(ctx, "Found raw URL \"%s\"", string(gitRawURL))
+
 	fullURLRegExp := regexp.MustCompile(fullURLRegex)
 	fullURLMatches := fullURLRegExp.FindSubmatch(gitRawURL)
 
 	if len(fullURLMatches) != 3 {
-		return "", errors.New("Could not convert raw URL")
+		return nil, errors.New("Could not convert raw URL")
 	}
 
 	parsedURL := fullURLRegExp.ReplaceAll(gitRawURL, []byte("https://$1/$2"))
-	gitClient.Logger.Logf("Found parsed URL \"%s\"", string(parsedURL))
+	gitClient.Logger.LogfContext(ctx, "Found parsed URL \"%s\"", string(parsedURL))
+
+	treeName := gitHead.TreeName()
+	if gitHead.Branch != "" {
+		treeName = gitClient.UpstreamBranch(ctx, gitConfig, gitHead.Branch)
+	}
+
+	repository := &Repository{
+		Config:   gitConfig,
+		GitDir:   gitDir,
+		Head:     gitHead,
+		TreeName: treeName,
+		URL:      string(parsedURL),
+		WorkTree: workTree,
+	}
+
+	return repository, nil
+}
 
-	fullURL := fmt.Sprintf("%s/tree/%s", string(parsedURL), string(gitBranch))
+// GetFullURL takes a directory and (given it's inside a git repository) returns the repository's full URL.
+func (gitClient *Client) GetFullURL(ctx context.Context, mainDir string) (string, error) {
+	repository, repositoryError := gitClient.OpenRepository(ctx, mainDir)
+
+	if repositoryError != nil {
+		return "", repositoryError
+	}
 
-	return fullURL, nil
+	return repository.TreeURL(), nil
 }
 
 // GetPullRequestURL gets the according pull request URL from GitHub
 // if there is one
-func (gitClient *Client) GetPullRequestURL(gitFullURL string) (string, error) {
+func (gitClient *Client) GetPullRequestURL(ctx context.Context, gitFullURL string) (string, error) {
 	pullRequestRegExp := regexp.MustCompile(pullRequestRegex)
 	fullURLMatches := pullRequestRegExp.FindStringSubmatch(gitFullURL)
 
@@ -229,11 +390,17 @@ func (gitClient *Client) GetPullRequestURL(gitFullURL string) (string, error) {
 	repoName := fullURLMatches[2]
 	repoBranch := fullURLMatches[3]
 
-	gitClient.Logger.Logf("Got user \"%s\", repo name \"%s\" and branch \"%s\"", repoUser, repoName, repoBranch)
+	// the tree name is escaped in the URL (e.g. "%23" for "#")
+	if unescapedBranch, unescapeError := url.PathUnescape(repoBranch); unescapeError == nil {
+		repoBranch = unescapedBranch
+	}
+
+	gitClient.Logger.LogfContext(ctx, "Got user \"%s\", repo name \"%s\" and branch \"%s\"", repoUser, repoName, repoBranch)
 
+	repoContext := simplelogger.ContextWithFields(ctx, "repo", repoUser+"/"+repoName)
 	githubClient := github.New(gitClient.Timeout, gitClient.DebugMode)
 
-	pullRequest, pullRequestError := githubClient.GetPullRequestByBranch(repoUser, repoName, repoBranch)
+	pullRequest, pullRequestError := githubClient.GetPullRequestByBranch(repoContext, repoUser, repoName, repoBranch)
 	if pullRequestError != nil {
 		return "", pullRequestError
 	}
@@ -0,0 +1,180 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package git
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpstreamBranch(t *testing.T) {
+	config := parseTestConfig(t, strings.Join([]string{
+		"[remote \"origin\"]",
+		"	url = https://github.com/user/repo.git",
+		"[remote \"upstream\"]",
+		"	url = https://github.com/upstream/repo.git",
+		"[branch \"feature\"]",
+		"	remote = origin",
+		"	merge = refs/heads/jdoe/feature",
+		"[branch \"same\"]",
+		"	remote = origin",
+		"	merge = refs/heads/same",
+		"[branch \"local\"]",
+		"	remote = .",
+		"	merge = refs/heads/main",
+		"[branch \"nomerge\"]",
+		"	remote = origin",
+		"[branch \"noremote\"]",
+		"	merge = refs/heads/other",
+		"[branch \"fork\"]",
+		"	remote = upstream",
+		"	merge = refs/heads/upstream-name",
+	}, "\n"))
+
+	gitClient := New(0, false)
+
+	testCases := []struct {
+		branch   string
+		flag     string
+		upstream string
+	}{
+		{"feature", "", "jdoe/feature"},
+		{"feature", "origin", "jdoe/feature"},
+		{"same", "", "same"},
+		{"local", "", "local"},
+		{"nomerge", "", "nomerge"},
+		{"noremote", "", "noremote"},
+		{"untracked", "", "untracked"},
+		{"fork", "", "upstream-name"},
+		{"fork", "origin", "fork"},
+		{"feature", "upstream", "feature"},
+	}
+
+	for _, testCase := range testCases {
+		gitClient.Remote = testCase.flag
+
+		if upstream := gitClient.UpstreamBranch(context.Background(), config, testCase.branch); upstream != testCase.upstream {
+			t.Errorf("UpstreamBranch(%q) with --remote %q = %q, want %q", testCase.branch, testCase.flag, upstream, testCase.upstream)
+		}
+	}
+}
+
+func TestOpenRepositoryUpstream(t *testing.T) {
+	gitDir := newTestGitDir(t, map[string]string{
+		"HEAD": "ref: refs/heads/feature\n",
+		"config": strings.Join([]string{
+			"[remote \"origin\"]",
+			"	url = git@github.com:user/repo.git",
+			"[branch \"feature\"]",
+			"	remote = origin",
+			"	merge = refs/heads/jdoe/feature",
+		}, "\n"),
+	})
+
+	repository, openError := New(0, false).OpenRepository(context.Background(), filepath.Dir(gitDir))
+	if openError != nil {
+		t.Fatal(openError)
+	}
+
+	if treeURL := repository.TreeURL(); treeURL != "https://github.com/user/repo/tree/jdoe/feature" {
+		t.Errorf("TreeURL() = %q, want the upstream branch", treeURL)
+	}
+}
+
+func TestFindGitDirSubmodule(t *testing.T) {
+	superproject := t.TempDir()
+	modulesDir := filepath.Join(superproject, ".git", "modules", "sm")
+	writeTestFile(t, filepath.Join(superproject, ".git", "HEAD"), "ref: refs/heads/main\n")
+	writeTestFile(t, filepath.Join(superproject, ".git", "config"), "[remote \"origin\"]\n\turl = https://github.com/user/superproject.git\n")
+	writeTestFile(t, filepath.Join(modulesDir, "HEAD"), commitSHA+"\n")
+	writeTestFile(t, filepath.Join(modulesDir, "config"), "[remote \"origin\"]\n\turl = https://github.com/user/submodule.git\n")
+
+	submodule := filepath.Join(superproject, "sm")
+	writeTestFile(t, filepath.Join(submodule, ".git"), "gitdir: ../.git/modules/sm\n")
+	writeTestFile(t, filepath.Join(submodule, "src", "main.go"), "package main\n")
+
+	gitClient := New(0, false)
+
+	gitDir, gitDirError := gitClient.FindGitDir(context.Background(), filepath.Join(submodule, "src"))
+	if gitDirError != nil {
+		t.Fatal(gitDirError)
+	}
+	if gitDir != modulesDir {
+		t.Errorf("FindGitDir() = %q, want %q", gitDir, modulesDir)
+	}
+
+	commonDir, commonDirError := gitClient.FindCommonDir(context.Background(), gitDir)
+	if commonDirError != nil || commonDir != modulesDir {
+		t.Errorf("FindCommonDir() = %q (%v), want %q", commonDir, commonDirError, modulesDir)
+	}
+
+	repository, openError := gitClient.OpenRepository(context.Background(), filepath.Join(submodule, "src"))
+	if openError != nil {
+		t.Fatal(openError)
+	}
+
+	if repository.URL != "https://github.com/user/submodule" || repository.WorkTree != submodule || repository.TreeName != commitSHA {
+		t.Errorf("unexpected repository URL %q, work tree %q and tree name %q", repository.URL, repository.WorkTree, repository.TreeName)
+	}
+}
+
+func TestFindGitDirErrors(t *testing.T) {
+	testCases := []struct {
+		content string
+		message string
+	}{
+		{content: "", message: "No gitdir found"},
+		{content: "not a gitdir file\n", message: "No gitdir found"},
+		{content: "gitdir: ../missing\n", message: "Could not find the git directory"},
+	}
+
+	gitClient := New(0, false)
+
+	for _, testCase := range testCases {
+		workTree := t.TempDir()
+		writeTestFile(t, filepath.Join(workTree, ".git"), testCase.content)
+
+		_, gitDirError := gitClient.FindGitDir(context.Background(), workTree)
+		if gitDirError == nil || !strings.Contains(gitDirError.Error(), testCase.message) {
+			t.Errorf("FindGitDir() with %q: expected an error with %q, got %v", testCase.content, testCase.message, gitDirError)
+		}
+
+		if _, openError := gitClient.OpenRepository(context.Background(), workTree); openError == nil {
+			t.Errorf("OpenRepository() with %q returned no error", testCase.content)
+		}
+	}
+}
+
+func TestFindCommonDir(t *testing.T) {
+	gitDir := newTestGitDir(t, map[string]string{"HEAD": "ref: refs/heads/main\n"})
+	relativeWorktree := filepath.Join(gitDir, "worktrees", "relative")
+	absoluteWorktree := filepath.Join(gitDir, "worktrees", "absolute")
+	writeTestFile(t, filepath.Join(relativeWorktree, "commondir"), "../..\n")
+	writeTestFile(t, filepath.Join(absoluteWorktree, "commondir"), gitDir+"\n")
+
+	gitClient := New(0, false)
+
+	for _, worktreeGitDir := range []string{gitDir, relativeWorktree, absoluteWorktree} {
+		commonDir, commonDirError := gitClient.FindCommonDir(context.Background(), worktreeGitDir)
+		if commonDirError != nil || commonDir != gitDir {
+			t.Errorf("FindCommonDir(%q) = %q (%v), want %q", worktreeGitDir, commonDir, commonDirError, gitDir)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Repository is a git repository with the information needed to build GitHub URLs
+type Repository struct {
+	Config   *Config
+	GitDir   string
+	Head     *Head
+	TreeName string // The branch (or its upstream branch), tag or commit to open
+	URL      string // The GitHub URL of the selected remote, e.g. "https://github.com/user/repo"
+	WorkTree string // The root directory of the checkout
+}
+
+// Location is a file (optionally with lines) or directory to open
+type Location struct {
+	EndLine   int    // The last line of a range, 0 for a single line
+	IsDir     bool   // Whether the path is a directory
+	Path      string // The absolute path
+	StartLine int    // The first line, 0 for the whole file
+}
+
+const lineRegex = `^(.+?):(\d+)(?:-(\d+))?$`
+
+var linesOnDirectoryError = errors.New("Lines can only be opened in files")
+
+// ParseLocation takes a path with optional lines ("file.go", "file.go:42" or
+// "file.go:10-20") and returns it as location
+func ParseLocation(path string) (*Location, error) {
+	var (
+		endLine   int
+		startLine int
+	)
+
+	if _, statError := os.Stat(path); os.IsNotExist(statError) {
+		if lineMatches := regexp.MustCompile(lineRegex).FindStringSubmatch(path); lineMatches != nil {
+			path = lineMatches[1]
+			startLine, _ = strconv.Atoi(lineMatches[2])
+			endLine, _ = strconv.Atoi(lineMatches[3])
+
+			if startLine == 0 {
+				return nil, fmt.Errorf("Invalid line in \"%s\"", lineMatches[0])
+			}
+		}
+	}
+
+	absolutePath, absError := filepath.Abs(path)
+
+	if absError != nil {
+		return nil, absError
+	}
+
+	fileInfo, statError := os.Stat(absolutePath)
+
+	if os.IsNotExist(statError) {
+		return nil, fmt.Errorf("Could not find \"%s\"", path)
+	} else if statError != nil {
+		return nil, statError
+	}
+
+	if fileInfo.IsDir() && startLine != 0 {
+		return nil, linesOnDirectoryError
+	}
+
+	if endLine != 0 && endLine < startLine {
+		return nil, fmt.Errorf("Invalid line range %d-%d", startLine, endLine)
+	}
+
+	if endLine == startLine {
+		endLine = 0
+	}
+
+	location := &Location{
+		EndLine:   endLine,
+		IsDir:     fileInfo.IsDir(),
+		Path:      absolutePath,
+		StartLine: startLine,
+	}
+
+	return location, nil
+}
+
+// Dir returns the location if it is a directory or else the directory of the file
+func (location *Location) Dir() string {
+	if location.IsDir {
+		return location.Path
+	}
+
+	return filepath.Dir(location.Path)
+}
+
+// Fragment returns the line anchor for GitHub ("#L42" or "#L10-L20") or an
+// empty string if the location has no lines
+func (location *Location) Fragment() string {
+	switch {
+	case location.StartLine == 0:
+		return ""
+	case location.EndLine == 0:
+		return fmt.Sprintf("#L%d", location.StartLine)
+	default:
+		return fmt.Sprintf("#L%d-L%d", location.StartLine, location.EndLine)
+	}
+}
+
+// TreeURL returns the URL of the repository's tree at TreeName
+func (repository *Repository) TreeURL() string {
+	return fmt.Sprintf("%s/tree/%s", repository.URL, escapePath(repository.TreeName))
+}
+
+// IsRoot reports whether the location is the root directory of the checkout
+func (repository *Repository) IsRoot(location *Location) bool {
+	relativePath, relError := repository.RelativePath(location)
+	return relError == nil && relativePath == ""
+}
+
+// RelativePath returns the path of the location relative to the root directory
+// of the checkout, with slashes as separators
+func (repository *Repository) RelativePath(location *Location) (string, error) {
+	relativePath, relError := filepath.Rel(repository.WorkTree, location.Path)
+
+	if relError != nil {
+		return "", relError
+	}
+
+	relativePath = filepath.ToSlash(relativePath)
+
+	if relativePath == ".." || strings.HasPrefix(relativePath, "../") {
+		return "", fmt.Errorf("\"%s\" is outside of the repository \"%s\"", location.Path, repository.WorkTree)
+	}
+
+	if relativePath == "." {
+		return "", nil
+	}
+
+	return relativePath, nil
+}
+
+// LocationURL returns the URL of a file ("/blob/<tree>/<path>#L42") or a
+// directory ("/tree/<tree>/<path>") in the repository
+func (repository *Repository) LocationURL(location *Location) (string, error) {
+	return repository.locationURL(location, repository.TreeName)
+}
+
+// PermalinkURL returns the URL of the location pinned to the commit, e.g.
+// "/blob/<sha>/<path>#L42" or "/tree/<sha>"
+func (repository *Repository) PermalinkURL(location *Location, sha string) (string, error) {
+	return repository.locationURL(location, sha)
+}
+
+func (repository *Repository) locationURL(location *Location, treeName string) (string, error) {
+	relativePath, relError := repository.RelativePath(location)
+
+	if relError != nil {
+		return "", relError
+	}
+
+	treeName = escapePath(treeName)
+
+	if relativePath == "" {
+		return fmt.Sprintf("%s/tree/%s", repository.URL, treeName), nil
+	}
+
+	kind := "blob"
+	if location.IsDir {
+		kind = "tree"
+	}
+
+	return fmt.Sprintf("%s/%s/%s/%s%s", repository.URL, kind, treeName, escapePath(relativePath), location.Fragment()), nil
+}
+
+// escapePath escapes every segment of a slash-separated path for a URL
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+
+	for index, segment := range segments {
+		segments[index] = url.PathEscape(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,198 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLocation(t *testing.T) {
+	baseDir := t.TempDir()
+	writeTestFile(t, filepath.Join(baseDir, "main.go"), "package main\n")
+	writeTestFile(t, filepath.Join(baseDir, "odd:7"), "")
+	os.Mkdir(filepath.Join(baseDir, "docs"), 0755)
+
+	testCases := []struct {
+		endLine   int
+		isDir     bool
+		path      string
+		startLine int
+		wantPath  string
+	}{
+		{path: "main.go", wantPath: "main.go"},
+		{path: "main.go:42", startLine: 42, wantPath: "main.go"},
+		{path: "main.go:10-20", startLine: 10, endLine: 20, wantPath: "main.go"},
+		{path: "main.go:5-5", startLine: 5, wantPath: "main.go"},
+		{path: "docs", isDir: true, wantPath: "docs"},
+		{path: "odd:7", wantPath: "odd:7"},
+	}
+
+	for _, testCase := range testCases {
+		location, parseError := ParseLocation(filepath.Join(baseDir, testCase.path))
+		if parseError != nil {
+			t.Errorf("%s: %s", testCase.path, parseError)
+			continue
+		}
+
+		if location.Path != filepath.Join(baseDir, testCase.wantPath) || location.IsDir != testCase.isDir ||
+			location.StartLine != testCase.startLine || location.EndLine != testCase.endLine {
+			t.Errorf("%s: unexpected location %+v", testCase.path, *location)
+		}
+	}
+}
+
+func TestParseLocationRelative(t *testing.T) {
+	workingDir, _ := os.Getwd()
+
+	location, parseError := ParseLocation("location.go:3")
+	if parseError != nil {
+		t.Fatal(parseError)
+	}
+
+	if location.Path != filepath.Join(workingDir, "location.go") || location.StartLine != 3 {
+		t.Errorf("unexpected location %+v", *location)
+	}
+}
+
+func TestParseLocationErrors(t *testing.T) {
+	baseDir := t.TempDir()
+	writeTestFile(t, filepath.Join(baseDir, "main.go"), "package main\n")
+	os.Mkdir(filepath.Join(baseDir, "docs"), 0755)
+
+	testCases := []struct {
+		message string
+		path    string
+	}{
+		{message: "Invalid line", path: "main.go:0"},
+		{message: "Invalid line", path: "main.go:0-3"},
+		{message: "Invalid line range 20-10", path: "main.go:20-10"},
+		{message: "Lines can only be opened in files", path: "docs:3"},
+		{message: "Could not find", path: "missing.go:3"},
+		{message: "Could not find", path: "missing.go"},
+	}
+
+	for _, testCase := range testCases {
+		_, parseError := ParseLocation(filepath.Join(baseDir, testCase.path))
+		if parseError == nil || !strings.Contains(parseError.Error(), testCase.message) {
+			t.Errorf("%s: expected an error with %q, got %v", testCase.path, testCase.message, parseError)
+		}
+	}
+}
+
+func TestLocationFragment(t *testing.T) {
+	testCases := []struct {
+		fragment string
+		location Location
+	}{
+		{fragment: "", location: Location{}},
+		{fragment: "#L42", location: Location{StartLine: 42}},
+		{fragment: "#L10-L20", location: Location{StartLine: 10, EndLine: 20}},
+	}
+
+	for _, testCase := range testCases {
+		if fragment := testCase.location.Fragment(); fragment != testCase.fragment {
+			t.Errorf("%+v: expected %q, got %q", testCase.location, testCase.fragment, fragment)
+		}
+	}
+}
+
+func TestLocationURL(t *testing.T) {
+	workTree := filepath.Join(t.TempDir(), "repo")
+	repository := &Repository{
+		TreeName: "feature/#1?x%",
+		URL:      "https://github.com/user/repo",
+		WorkTree: workTree,
+	}
+
+	if treeURL := repository.TreeURL(); treeURL != "https://github.com/user/repo/tree/feature/%231%3Fx%25" {
+		t.Errorf("unexpected tree URL %q", treeURL)
+	}
+
+	testCases := []struct {
+		location Location
+		url      string
+	}{
+		{location: Location{IsDir: true, Path: workTree}, url: "https://github.com/user/repo/tree/feature/%231%3Fx%25"},
+		{location: Location{IsDir: true, Path: filepath.Join(workTree, "docs")}, url: "https://github.com/user/repo/tree/feature/%231%3Fx%25/docs"},
+		{location: Location{Path: filepath.Join(workTree, "cmd", "main.go"), StartLine: 42}, url: "https://github.com/user/repo/blob/feature/%231%3Fx%25/cmd/main.go#L42"},
+		{location: Location{Path: filepath.Join(workTree, "my file#1.md"), StartLine: 1, EndLine: 3}, url: "https://github.com/user/repo/blob/feature/%231%3Fx%25/my%20file%231.md#L1-L3"},
+	}
+
+	for _, testCase := range testCases {
+		locationURL, urlError := repository.LocationURL(&testCase.location)
+		if urlError != nil {
+			t.Errorf("%s: %s", testCase.location.Path, urlError)
+			continue
+		}
+
+		if locationURL != testCase.url {
+			t.Errorf("%s: expected %q, got %q", testCase.location.Path, testCase.url, locationURL)
+		}
+	}
+
+	permalinkURL, urlError := repository.PermalinkURL(&Location{Path: filepath.Join(workTree, "main.go")}, "0123456789abcdef0123456789abcdef01234567")
+	if urlError != nil || permalinkURL != "https://github.com/user/repo/blob/0123456789abcdef0123456789abcdef01234567/main.go" {
+		t.Errorf("unexpected permalink %q (%v)", permalinkURL, urlError)
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	baseDir := t.TempDir()
+	workTree := filepath.Join(baseDir, "repo")
+	repository := &Repository{URL: "https://github.com/user/repo", WorkTree: workTree}
+
+	testCases := []struct {
+		isRoot       bool
+		path         string
+		relativePath string
+	}{
+		{isRoot: true, path: workTree, relativePath: ""},
+		{path: filepath.Join(workTree, "a", "b.go"), relativePath: "a/b.go"},
+		{path: filepath.Join(workTree, "..repo"), relativePath: "..repo"},
+	}
+
+	for _, testCase := range testCases {
+		location := &Location{Path: testCase.path}
+
+		relativePath, relError := repository.RelativePath(location)
+		if relError != nil || relativePath != testCase.relativePath {
+			t.Errorf("%s: expected %q, got %q (%v)", testCase.path, testCase.relativePath, relativePath, relError)
+		}
+
+		if isRoot := repository.IsRoot(location); isRoot != testCase.isRoot {
+			t.Errorf("%s: expected IsRoot to be %t", testCase.path, testCase.isRoot)
+		}
+	}
+
+	for _, outsidePath := range []string{baseDir, filepath.Join(baseDir, "other", "main.go"), filepath.Join(baseDir, "repo2")} {
+		location := &Location{Path: outsidePath}
+
+		if _, relError := repository.RelativePath(location); relError == nil || !strings.Contains(relError.Error(), "is outside of the repository") {
+			t.Errorf("%s: expected an outside of the repository error, got %v", outsidePath, relError)
+		}
+		if _, urlError := repository.LocationURL(location); urlError == nil {
+			t.Errorf("%s: LocationURL did not fail", outsidePath)
+		}
+		if repository.IsRoot(location) {
+			t.Errorf("%s: IsRoot is true", outsidePath)
+		}
+	}
+}
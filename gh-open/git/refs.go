@@ -0,0 +1,363 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package git
+
+import (
+	"bufio"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Head describes what is checked out in a git directory
+type Head struct {
+	Branch string // The checked out branch, empty if HEAD is detached
+	SHA    string // The commit of a detached HEAD
+	Tag    string // A tag pointing to the commit of a detached HEAD
+}
+
+// PackedRef is a reference from the packed-refs file
+type PackedRef struct {
+	Name   string
+	Peeled string // The commit an annotated tag points to
+	SHA    string
+}
+
+const (
+	maxSymbolicRefDepth    = 5
+	rebaseHeadNameDetached = "detached HEAD"
+	shaRegex               = `^[0-9a-f]{40}([0-9a-f]{24})?$`
+)
+
+var (
+	noHeadError             = errors.New("No branch or commit found in git HEAD file")
+	tooManySymbolicRefError = errors.New("Too many nested symbolic refs")
+)
+
+// TreeName returns the name to use in a GitHub tree URL: the branch, the tag or
+// the commit SHA
+func (head *Head) TreeName() string {
+	switch {
+	case head.Branch != "":
+		return head.Branch
+	case head.Tag != "":
+		return head.Tag
+	default:
+		return head.SHA
+	}
+}
+
+// ParseHead takes a git directory and returns what is checked out. If HEAD is
+// detached during a rebase, the branch being rebased is returned.
+func (gitClient *Client) ParseHead(ctx context.Context, gitDir string) (*Head, error) {
+	gitHeadFile, absError := filepath.Abs(filepath.Join(gitDir, "HEAD"))
+
+	if absError != nil {
+		return nil, absError
+	}
+
+	if _, statError := os.Stat(gitHeadFile); os.IsNotExist(statError) {
+		return nil, fmt.Errorf("Could not find git HEAD file in \"%s\"", gitDir)
+	}
+
+	gitHead, readFileError := gitClient.readFile(gitHeadFile)
+
+	if readFileError != nil {
+		return nil, readFileError
+	}
+
+	headContent := strings.TrimSpace(string(*gitHead))
+	gitClient.Logger.LogfContext(ctx, "Read git head file: \"%s\"", headContent)
+
+	gitBranchRegExp := regexp.MustCompile(gitBranchRegex)
+	if branchMatches := gitBranchRegExp.FindStringSubmatch(headContent); len(branchMatches) == 2 {
+		return &Head{Branch: branchMatches[1]}, nil
+	}
+
+	if !regexp.MustCompile(shaRegex).MatchString(headContent) {
+		return nil, noHeadError
+	}
+
+	if rebaseBranch := gitClient.findRebaseBranch(gitDir); rebaseBranch != "" {
+		gitClient.Logger.LogfContext(ctx, "Found branch \"%s\" being rebased", rebaseBranch)
+		return &Head{Branch: rebaseBranch, SHA: headContent}, nil
+	}
+
+	head := &Head{SHA: headContent}
+
+	commonDir, commonDirError := gitClient.FindCommonDir(ctx, gitDir)
+
+	if commonDirError != nil {
+		return nil, commonDirError
+	}
+
+	tag, tagError := gitClient.findTag(commonDir, headContent)
+
+	if tagError != nil {
+		return nil, tagError
+	}
+
+	if tag != "" {
+		gitClient.Logger.LogfContext(ctx, "Found tag \"%s\" for detached HEAD", tag)
+		head.Tag = tag
+	}
+
+	return head, nil
+}
+
+// ResolveRef takes a git directory and a ref (e.g. "HEAD" or "refs/heads/main")
+// and returns the commit SHA it points to. Symbolic refs are followed, refs are
+// read from loose files and from packed-refs.
+func (gitClient *Client) ResolveRef(ctx context.Context, gitDir string, refName string) (string, error) {
+	commonDir, commonDirError := gitClient.FindCommonDir(ctx, gitDir)
+
+	if commonDirError != nil {
+		return "", commonDirError
+	}
+
+	shaRegExp := regexp.MustCompile(shaRegex)
+
+	for depth := 0; depth <= maxSymbolicRefDepth; depth++ {
+		value, readError := gitClient.readRef(gitDir, commonDir, refName)
+
+		if readError != nil {
+			return "", readError
+		}
+
+		if strings.HasPrefix(value, "ref: ") {
+			refName = strings.TrimSpace(strings.TrimPrefix(value, "ref: "))
+			continue
+		}
+
+		if !shaRegExp.MatchString(value) {
+			return "", fmt.Errorf("Invalid value \"%s\" of ref \"%s\"", value, refName)
+		}
+
+		gitClient.Logger.LogfContext(ctx, "Resolved ref \"%s\" to \"%s\"", refName, value)
+		return value, nil
+	}
+
+	return "", tooManySymbolicRefError
+}
+
+// readRef returns the content of a loose ref or the SHA of a packed ref.
+// HEAD and the refs below refs/worktree/ and refs/bisect/ belong to the
+// worktree, all others to the common directory.
+func (gitClient *Client) readRef(gitDir string, commonDir string, refName string) (string, error) {
+	refDir := commonDir
+	if !strings.Contains(refName, "/") || strings.HasPrefix(refName, "refs/worktree/") || strings.HasPrefix(refName, "refs/bisect/") {
+		refDir = gitDir
+	}
+
+	content, readFileError := gitClient.readFile(filepath.Join(refDir, filepath.FromSlash(refName)))
+
+	if readFileError == nil {
+		return strings.TrimSpace(string(*content)), nil
+	}
+
+	if !os.IsNotExist(readFileError) {
+		return "", readFileError
+	}
+
+	packedRefs, packedRefsError := gitClient.ParsePackedRefs(commonDir)
+
+	if packedRefsError != nil {
+		return "", packedRefsError
+	}
+
+	for _, packedRef := range packedRefs {
+		if packedRef.Name == refName {
+			return packedRef.SHA, nil
+		}
+	}
+
+	return "", fmt.Errorf("Could not resolve ref \"%s\"", refName)
+}
+
+// findRebaseBranch returns the branch of an in-progress rebase or an empty
+// string if there is none
+func (gitClient *Client) findRebaseBranch(gitDir string) string {
+	for _, rebaseDir := range []string{"rebase-merge", "rebase-apply"} {
+		headName, readFileError := gitClient.readFile(filepath.Join(gitDir, rebaseDir, "head-name"))
+
+		if readFileError != nil {
+			continue
+		}
+
+		branch := strings.TrimSpace(string(*headName))
+
+		if branch != rebaseHeadNameDetached && strings.HasPrefix(branch, "refs/heads/") {
+			return strings.TrimPrefix(branch, "refs/heads/")
+		}
+	}
+
+	return ""
+}
+
+// findTag returns the first tag (in alphabetical order) which points to the
+// commit, either as loose ref in refs/tags or in packed-refs
+func (gitClient *Client) findTag(commonDir string, sha string) (string, error) {
+	var tags []string
+
+	tagsDir := filepath.Join(commonDir, "refs", "tags")
+	looseTags := make(map[string]bool)
+
+	walkError := filepath.Walk(tagsDir, func(path string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if fileInfo.IsDir() {
+			return nil
+		}
+
+		relativePath, relError := filepath.Rel(tagsDir, path)
+		if relError != nil {
+			return relError
+		}
+
+		tag := filepath.ToSlash(relativePath)
+		looseTags[tag] = true
+
+		content, readFileError := gitClient.readFile(path)
+		if readFileError != nil {
+			return readFileError
+		}
+
+		tagSHA := strings.TrimSpace(string(*content))
+		if tagSHA == sha || gitClient.peelTag(commonDir, tagSHA) == sha {
+			tags = append(tags, tag)
+		}
+
+		return nil
+	})
+
+	if walkError != nil {
+		return "", walkError
+	}
+
+	packedRefs, packedRefsError := gitClient.ParsePackedRefs(commonDir)
+
+	if packedRefsError != nil {
+		return "", packedRefsError
+	}
+
+	for _, packedRef := range packedRefs {
+		if !strings.HasPrefix(packedRef.Name, "refs/tags/") {
+			continue
+		}
+
+		tag := strings.TrimPrefix(packedRef.Name, "refs/tags/")
+
+		// a loose tag overrides a packed one with the same name
+		if !looseTags[tag] && (packedRef.SHA == sha || packedRef.Peeled == sha) {
+			tags = append(tags, tag)
+		}
+	}
+
+	if len(tags) == 0 {
+		return "", nil
+	}
+
+	sort.Strings(tags)
+	return tags[0], nil
+}
+
+// peelTag returns the object an annotated tag points to or an empty string if
+// the object is not a loose tag object
+func (gitClient *Client) peelTag(commonDir string, sha string) string {
+	if len(sha) < 3 {
+		return ""
+	}
+
+	file, openError := os.Open(filepath.Join(commonDir, "objects", sha[:2], sha[2:]))
+
+	if openError != nil {
+		return ""
+	}
+
+	defer file.Close()
+
+	reader, zlibError := zlib.NewReader(file)
+
+	if zlibError != nil {
+		return ""
+	}
+
+	defer reader.Close()
+
+	// a tag object starts with "tag <size>\x00object <sha>\n"
+	header := make([]byte, 128)
+	length, _ := io.ReadFull(reader, header)
+	tagMatches := regexp.MustCompile(`^tag \d+\x00object ([0-9a-f]+)\n`).FindSubmatch(header[:length])
+
+	if len(tagMatches) != 2 {
+		return ""
+	}
+
+	return string(tagMatches[1])
+}
+
+// ParsePackedRefs takes a git directory and returns the references from its
+// packed-refs file (or none if there is no such file).
+func (gitClient *Client) ParsePackedRefs(gitDir string) ([]PackedRef, error) {
+	var packedRefs []PackedRef
+
+	file, openError := os.Open(filepath.Join(gitDir, "packed-refs"))
+
+	if os.IsNotExist(openError) {
+		return nil, nil
+	}
+
+	if openError != nil {
+		return nil, openError
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "^"):
+			if len(packedRefs) > 0 {
+				packedRefs[len(packedRefs)-1].Peeled = strings.TrimPrefix(line, "^")
+			}
+		default:
+			fields := strings.Fields(line)
+			if len(fields) == 2 {
+				packedRefs = append(packedRefs, PackedRef{Name: fields[1], SHA: fields[0]})
+			}
+		}
+	}
+
+	return packedRefs, scanner.Err()
+}
@@ -0,0 +1,316 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	commitSHA = "1111111111111111111111111111111111111111"
+	otherSHA  = "2222222222222222222222222222222222222222"
+	tagSHA    = "3333333333333333333333333333333333333333"
+)
+
+// newTestGitDir creates a git directory with the files (relative path to
+// content) and returns its path
+func newTestGitDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	gitDir := filepath.Join(t.TempDir(), ".git")
+
+	for fileName, content := range files {
+		writeTestFile(t, filepath.Join(gitDir, filepath.FromSlash(fileName)), content)
+	}
+
+	return gitDir
+}
+
+// writeTagObject writes a loose annotated tag object pointing to the commit
+func writeTagObject(t *testing.T, gitDir string, sha string, commit string) {
+	t.Helper()
+
+	body := fmt.Sprintf("object %s\ntype commit\ntag v1\ntagger A <a@b> 0 +0000\n\nmessage\n", commit)
+
+	var buffer bytes.Buffer
+	writer := zlib.NewWriter(&buffer)
+	fmt.Fprintf(writer, "tag %d\x00%s", len(body), body)
+	writer.Close()
+
+	writeTestFile(t, filepath.Join(gitDir, "objects", sha[:2], sha[2:]), buffer.String())
+}
+
+func TestParseHead(t *testing.T) {
+	testCases := []struct {
+		name     string
+		files    map[string]string
+		branch   string
+		tag      string
+		treeName string
+	}{
+		{
+			name:     "branch",
+			files:    map[string]string{"HEAD": "ref: refs/heads/main\n"},
+			branch:   "main",
+			treeName: "main",
+		},
+		{
+			name:     "detached without tag",
+			files:    map[string]string{"HEAD": commitSHA + "\n", "refs/tags/v1": otherSHA + "\n"},
+			treeName: commitSHA,
+		},
+		{
+			name:     "loose tag",
+			files:    map[string]string{"HEAD": commitSHA + "\n", "refs/tags/release/v1": commitSHA + "\n"},
+			tag:      "release/v1",
+			treeName: "release/v1",
+		},
+		{
+			name: "packed tags",
+			files: map[string]string{
+				"HEAD": commitSHA + "\n",
+				"packed-refs": strings.Join([]string{
+					"# pack-refs with: peeled fully-peeled sorted",
+					otherSHA + " refs/heads/main",
+					tagSHA + " refs/tags/v2",
+					"^" + commitSHA,
+					commitSHA + " refs/tags/v3",
+				}, "\n"),
+			},
+			tag:      "v2",
+			treeName: "v2",
+		},
+		{
+			name: "loose tag overrides packed tag",
+			files: map[string]string{
+				"HEAD":         commitSHA + "\n",
+				"refs/tags/v1": otherSHA + "\n",
+				"packed-refs":  tagSHA + " refs/tags/v1\n^" + commitSHA + "\n",
+			},
+			treeName: commitSHA,
+		},
+		{
+			name: "rebase",
+			files: map[string]string{
+				"HEAD":                   commitSHA + "\n",
+				"rebase-merge/head-name": "refs/heads/feature\n",
+				"refs/tags/v1":           commitSHA + "\n",
+			},
+			branch:   "feature",
+			treeName: "feature",
+		},
+		{
+			name: "rebase of a detached HEAD",
+			files: map[string]string{
+				"HEAD":                   commitSHA + "\n",
+				"rebase-apply/head-name": "detached HEAD\n",
+			},
+			treeName: commitSHA,
+		},
+	}
+
+	gitClient := New(0, false)
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			gitDir := newTestGitDir(t, testCase.files)
+
+			head, headError := gitClient.ParseHead(context.Background(), gitDir)
+			if headError != nil {
+				t.Fatal(headError)
+			}
+
+			if head.Branch != testCase.branch || head.Tag != testCase.tag || head.TreeName() != testCase.treeName {
+				t.Errorf("ParseHead() = %+v (tree %q), want branch %q, tag %q and tree %q", head, head.TreeName(), testCase.branch, testCase.tag, testCase.treeName)
+			}
+		})
+	}
+}
+
+func TestParseHeadLooseAnnotatedTag(t *testing.T) {
+	gitDir := newTestGitDir(t, map[string]string{
+		"HEAD":         commitSHA + "\n",
+		"refs/tags/v1": tagSHA + "\n",
+	})
+	writeTagObject(t, gitDir, tagSHA, commitSHA)
+
+	head, headError := New(0, false).ParseHead(context.Background(), gitDir)
+	if headError != nil {
+		t.Fatal(headError)
+	}
+
+	if head.Tag != "v1" {
+		t.Errorf("ParseHead().Tag = %q, want \"v1\"", head.Tag)
+	}
+}
+
+func TestParseHeadInvalid(t *testing.T) {
+	gitDir := newTestGitDir(t, map[string]string{"HEAD": "garbage\n"})
+
+	if _, headError := New(0, false).ParseHead(context.Background(), gitDir); headError == nil {
+		t.Error("ParseHead() returned no error for an invalid HEAD")
+	}
+}
+
+func TestResolveRef(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files map[string]string
+		ref   string
+		sha   string
+	}{
+		{
+			name:  "loose branch",
+			files: map[string]string{"HEAD": "ref: refs/heads/main\n", "refs/heads/main": commitSHA + "\n"},
+			ref:   "HEAD",
+			sha:   commitSHA,
+		},
+		{
+			name:  "packed branch",
+			files: map[string]string{"HEAD": "ref: refs/heads/main\n", "packed-refs": otherSHA + " refs/heads/main\n"},
+			ref:   "HEAD",
+			sha:   otherSHA,
+		},
+		{
+			name: "loose ref overrides packed ref",
+			files: map[string]string{
+				"HEAD":            "ref: refs/heads/main\n",
+				"refs/heads/main": commitSHA + "\n",
+				"packed-refs":     otherSHA + " refs/heads/main\n",
+			},
+			ref: "HEAD",
+			sha: commitSHA,
+		},
+		{
+			name:  "detached HEAD",
+			files: map[string]string{"HEAD": commitSHA + "\n"},
+			ref:   "HEAD",
+			sha:   commitSHA,
+		},
+		{
+			name: "nested symbolic ref",
+			files: map[string]string{
+				"HEAD":               "ref: refs/heads/alias\n",
+				"refs/heads/alias":   "ref: refs/heads/feature\n",
+				"refs/heads/feature": otherSHA + "\n",
+			},
+			ref: "HEAD",
+			sha: otherSHA,
+		},
+	}
+
+	gitClient := New(0, false)
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			gitDir := newTestGitDir(t, testCase.files)
+
+			sha, resolveError := gitClient.ResolveRef(context.Background(), gitDir, testCase.ref)
+			if resolveError != nil {
+				t.Fatal(resolveError)
+			}
+
+			if sha != testCase.sha {
+				t.Errorf("ResolveRef(%q) = %q, want %q", testCase.ref, sha, testCase.sha)
+			}
+		})
+	}
+}
+
+func TestResolveRefErrors(t *testing.T) {
+	testCases := map[string]map[string]string{
+		"unborn branch": {"HEAD": "ref: refs/heads/main\n"},
+		"invalid value": {"HEAD": "ref: refs/heads/main\n", "refs/heads/main": "garbage\n"},
+		"symbolic loop": {"HEAD": "ref: refs/heads/a\n", "refs/heads/a": "ref: refs/heads/b\n", "refs/heads/b": "ref: refs/heads/a\n"},
+	}
+
+	gitClient := New(0, false)
+
+	for name, files := range testCases {
+		gitDir := newTestGitDir(t, files)
+
+		if _, resolveError := gitClient.ResolveRef(context.Background(), gitDir, "HEAD"); resolveError == nil {
+			t.Errorf("%s: ResolveRef() returned no error", name)
+		}
+	}
+}
+
+func TestResolveRefWorktree(t *testing.T) {
+	commonDir := newTestGitDir(t, map[string]string{
+		"HEAD":            "ref: refs/heads/main\n",
+		"refs/heads/main": commitSHA + "\n",
+		"packed-refs":     otherSHA + " refs/heads/feature\n",
+	})
+
+	worktreeGitDir := filepath.Join(commonDir, "worktrees", "feature")
+	writeTestFile(t, filepath.Join(worktreeGitDir, "HEAD"), "ref: refs/heads/feature\n")
+	writeTestFile(t, filepath.Join(worktreeGitDir, "commondir"), "../..\n")
+
+	workTree := t.TempDir()
+	writeTestFile(t, filepath.Join(workTree, ".git"), "gitdir: "+worktreeGitDir+"\n")
+
+	gitClient := New(0, false)
+
+	gitDir, gitDirError := gitClient.FindGitDir(context.Background(), workTree)
+	if gitDirError != nil {
+		t.Fatal(gitDirError)
+	}
+
+	if gitDir != worktreeGitDir {
+		t.Errorf("FindGitDir() = %q, want %q", gitDir, worktreeGitDir)
+	}
+
+	sha, resolveError := gitClient.ResolveRef(context.Background(), gitDir, "HEAD")
+	if resolveError != nil {
+		t.Fatal(resolveError)
+	}
+
+	if sha != otherSHA {
+		t.Errorf("ResolveRef(\"HEAD\") = %q, want %q", sha, otherSHA)
+	}
+}
+
+func TestParsePackedRefs(t *testing.T) {
+	gitDir := newTestGitDir(t, map[string]string{
+		"packed-refs": "# pack-refs with: peeled\n" + otherSHA + " refs/heads/main\n" + tagSHA + " refs/tags/v1\n^" + commitSHA + "\n",
+	})
+
+	packedRefs, parseError := New(0, false).ParsePackedRefs(gitDir)
+	if parseError != nil {
+		t.Fatal(parseError)
+	}
+
+	expectedRefs := []PackedRef{
+		{Name: "refs/heads/main", SHA: otherSHA},
+		{Name: "refs/tags/v1", Peeled: commitSHA, SHA: tagSHA},
+	}
+
+	if fmt.Sprint(packedRefs) != fmt.Sprint(expectedRefs) {
+		t.Errorf("ParsePackedRefs() = %v, want %v", packedRefs, expectedRefs)
+	}
+
+	if packedRefs, _ := New(0, false).ParsePackedRefs(t.TempDir()); len(packedRefs) != 0 {
+		t.Errorf("ParsePackedRefs() without packed-refs file = %v", packedRefs)
+	}
+}
@@ -18,6 +18,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package github
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -51,7 +52,7 @@ const baseURL = "https://api.github.com"
 
 // New returns a new instance of Client
 func New(timeout int, debugMode bool) *Client {
-	logger := simplelogger.New("gh-open/githubclient", debugMode, true)
+	logger := simplelogger.NewChild("gh-open", "githubclient", debugMode)
 
 	return &Client{
 		DebugMode: debugMode,
@@ -60,21 +61,26 @@ func New(timeout int, debugMode bool) *Client {
 	}
 }
 
-func (githubClient *Client) request(urlPath string) (*[]byte, error) {
+func (githubClient *Client) request(ctx context.Context, urlPath string) (*[]byte, error) {
 	timeout := time.Duration(githubClient.Timeout) * time.Millisecond
 	httpClient := &http.Client{Timeout: timeout}
 	fullURL := fmt.Sprintf("%s/%s", baseURL, urlPath)
 
-	githubClient.Logger.Logf("Sending GET request to \"%s\" with timeout \"%s\" ...", fullURL, timeout)
+	githubClient.Logger.LogfContext(ctx, "Sending GET request to \"%s\" with timeout \"%s\" ...", fullURL, timeout)
 
-	response, responseError := httpClient.Get(fullURL)
+	request, requestError := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if requestError != nil {
+		return nil, requestError
+	}
+
+	response, responseError := httpClient.Do(request)
 	if responseError != nil {
 		return nil, responseError
 	}
 
 	defer response.Body.Close()
 
-	githubClient.Logger.Logf("Got response status code \"%d\"", response.StatusCode)
+	githubClient.Logger.LogfContext(ctx, "Got response status code \"%d\"", response.StatusCode)
 
 	if response.StatusCode != 200 {
 		return nil, errors.New("Invalid response status code")
@@ -90,11 +96,11 @@ func (githubClient *Client) request(urlPath string) (*[]byte, error) {
 
 // GetPullRequests gets pull requests from GitHub,
 // see https://developer.github.com/v3/pulls/#list-pull-requests
-func (githubClient *Client) GetPullRequests(repoUser string, repoName string) (*[]PullRequest, error) {
+func (githubClient *Client) GetPullRequests(ctx context.Context, repoUser string, repoName string) (*[]PullRequest, error) {
 	var pullRequests *[]PullRequest
 
 	urlPath := fmt.Sprintf("repos/%s/%s/pulls", repoUser, repoName)
-	requestBuffer, requestError := githubClient.request(urlPath)
+	requestBuffer, requestError := githubClient.request(ctx, urlPath)
 	if requestError != nil {
 		return nil, requestError
 	}
@@ -104,14 +110,14 @@ func (githubClient *Client) GetPullRequests(repoUser string, repoName string) (*
 		return nil, unmarshalError
 	}
 
-	githubClient.Logger.Log("Got pull requests", *pullRequests)
+	githubClient.Logger.LogContext(ctx, "Got pull requests", *pullRequests)
 
 	return pullRequests, nil
 }
 
 // GetPullRequestByBranch returns a pull request URL for the specified branch if it exists
-func (githubClient *Client) GetPullRequestByBranch(repoUser string, repoName string, branch string) (string, error) {
-	pullRequests, pullRequestError := githubClient.GetPullRequests(repoUser, repoName)
+func (githubClient *Client) GetPullRequestByBranch(ctx context.Context, repoUser string, repoName string, branch string) (string, error) {
+	pullRequests, pullRequestError := githubClient.GetPullRequests(ctx, repoUser, repoName)
 
 	if pullRequestError != nil {
 		return "", pullRequestError
@@ -120,7 +126,7 @@ func (githubClient *Client) GetPullRequestByBranch(repoUser string, repoName str
 	for _, pullRequest := range *pullRequests {
 		if pullRequest.Head.Ref == branch {
 			pullRequestURL := pullRequest.Links.HTML.Href
-			githubClient.Logger.Logf("Got pull request URL \"%s\"", pullRequestURL)
+			githubClient.Logger.LogfContext(ctx, "Got pull request URL \"%s\"", pullRequestURL)
 			return pullRequestURL, nil
 		}
 	}
@@ -18,7 +18,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package main
 
 import (
-	"path/filepath"
+	"context"
 
 	"github.com/ffflorian/go-tools/gh-open/git"
 	"github.com/ffflorian/go-tools/gh-open/util"
@@ -42,11 +42,13 @@ func main() {
 
 	justPrint := utils.FlagContext.Bool("p")
 	justBranch := utils.FlagContext.Bool("b")
+	permalink := utils.FlagContext.Bool("l")
 	debugMode := utils.FlagContext.Bool("d")
 	timeout := utils.FlagContext.Int("t")
+	remote := utils.FlagContext.String("r")
 
 	if debugMode == true {
-		logger.Enabled = true
+		logger.SetEnabled(true)
 	}
 
 	logger.Log("Got arguments:", utils.FlagContext.Args()[1:])
@@ -63,21 +65,32 @@ func main() {
 		utils.FlagContext.Int("t")
 	}
 
-	gitClient := git.New(timeout, debugMode)
-
 	argsDir, argsDirError := utils.GetArgsDir()
 	utils.CheckError(argsDirError, true)
 
-	mainDir, absError := filepath.Abs(argsDir)
-	utils.CheckError(absError, false)
+	location, locationError := git.ParseLocation(argsDir)
+	utils.CheckError(locationError, false)
+
+	ctx := simplelogger.ContextWithFields(context.Background(), "path", location.Path)
+	gitClient := git.New(timeout, debugMode)
+	gitClient.Remote = remote
+
+	repository, repositoryError := gitClient.OpenRepository(ctx, location.Dir())
+	utils.CheckError(repositoryError, false)
 
-	fullURL, fullURLError := gitClient.GetFullURL(mainDir)
+	fullURL, fullURLError := repository.LocationURL(location)
 	utils.CheckError(fullURLError, false)
 
-	if justBranch == false {
-		pullRequest, pullRequestError := gitClient.GetPullRequestURL(fullURL)
+	if permalink == true {
+		sha, shaError := gitClient.ResolveRef(ctx, repository.GitDir, "HEAD")
+		utils.CheckError(shaError, false)
+
+		fullURL, fullURLError = repository.PermalinkURL(location, sha)
+		utils.CheckError(fullURLError, false)
+	} else if justBranch == false && repository.IsRoot(location) {
+		pullRequest, pullRequestError := gitClient.GetPullRequestURL(ctx, fullURL)
 		if pullRequestError != nil {
-			logger.Error(pullRequestError)
+			logger.ErrorContext(ctx, pullRequestError)
 		}
 		if pullRequest != "" {
 			fullURL = pullRequest
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/ffflorian/go-tools/simplelogger"
 	"github.com/simonleung8/flags"
 )
 
@@ -50,6 +51,8 @@ func (util *Util) CheckFlags() {
 	util.FlagContext.NewBoolFlag("print", "p", "just print the URL")
 	util.FlagContext.NewIntFlagWithDefault("timeout", "t", "Set a custom timeout for HTTP requests", 2000)
 	util.FlagContext.NewBoolFlag("branch", "b", "open the branch tree (and not the PR)")
+	util.FlagContext.NewBoolFlag("permalink", "l", "open the current commit (and not the branch or PR)")
+	util.FlagContext.NewStringFlag("remote", "r", "use this remote (default: the branch's remote or origin)")
 	util.FlagContext.NewBoolFlag("debug", "d", "enable debug mode")
 	util.FlagContext.NewBoolFlag("version", "v", "output the version number")
 	util.FlagContext.NewBoolFlag("help", "h", "output usage information")
@@ -58,7 +61,8 @@ func (util *Util) CheckFlags() {
 	util.CheckError(parseError, false)
 }
 
-// GetArgsDir returns the directory provided via arguments
+// GetArgsDir returns the directory or file (optionally with lines, e.g.
+// "main.go:10-20") provided via arguments
 func (util *Util) GetArgsDir() (string, error) {
 	args := util.FlagContext.Args()
 
@@ -75,9 +79,11 @@ func (util *Util) GetArgsDir() (string, error) {
 	return "", nil
 }
 
-// CheckError checks the error and if it exists, exits with exit code 1
+// CheckError checks the error and if it exists, flushes all loggers and exits
+// with exit code 1
 func (util *Util) CheckError(err error, printUsage bool) {
 	if err != nil {
+		simplelogger.FlushAll()
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		if printUsage {
 			fmt.Fprintln(os.Stderr, util.GetUsage())
@@ -89,15 +95,16 @@ func (util *Util) CheckError(err error, printUsage bool) {
 // GetUsage returns the usage text
 func (util *Util) GetUsage() string {
 	return fmt.Sprintf(
-		"%s\n\nUsage:\n  %s [options] [directory]\n\nOptions:\n%s",
+		"%s\n\nUsage:\n  %s [options] [directory|file[:line[-line]]]\n\nOptions:\n%s",
 		util.Description,
 		util.Name,
 		util.FlagContext.ShowUsage(2),
 	)
 }
 
-// LogAndExit logs one or more messages and exits with exit code 0
+// LogAndExit flushes all loggers, logs one or more messages and exits with exit code 0
 func (util *Util) LogAndExit(messages ...interface{}) {
+	simplelogger.FlushAll()
 	fmt.Println(messages...)
 	os.Exit(0)
 }
@@ -56,7 +56,7 @@ func main() {
 	timeout = utils.FlagContext.Int("t")
 
 	if debugMode == true {
-		logger.Enabled = true
+		logger.SetEnabled(true)
 	}
 
 	logger.Log("Got arguments:", utils.FlagContext.Args()[1:])
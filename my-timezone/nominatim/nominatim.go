@@ -56,7 +56,8 @@ const baseURL = "https://nominatim.openstreetmap.org"
 
 // New returns a new instance of Nominatim
 func New(timeout int, debugMode bool) *Client {
-	logger := simplelogger.New("my-timezone/nominatim", debugMode, true)
+	logger := simplelogger.NewChild("my-timezone", "nominatim", debugMode)
+
 	return &Client{
 		DebugMode: debugMode,
 		Logger:    logger,
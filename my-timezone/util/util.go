@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/ffflorian/go-tools/simplelogger"
 	"github.com/simonleung8/flags"
 )
 
@@ -69,9 +70,11 @@ func (util *Util) GetArgsLocation() (string, error) {
 	return "", errors.New("Argument for \"location\" not provided")
 }
 
-// CheckError checks the error and if it exists, exits with exit code 1
+// CheckError checks the error and if it exists, flushes all loggers and exits
+// with exit code 1
 func (util *Util) CheckError(err error, printUsage bool) {
 	if err != nil {
+		simplelogger.FlushAll()
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		if printUsage {
 			fmt.Fprintln(os.Stderr, util.GetUsage())
@@ -91,8 +94,9 @@ func (util *Util) GetUsage() string {
 	)
 }
 
-// LogAndExit logs one or more messages and exits with exit code 0
+// LogAndExit flushes all loggers, logs one or more messages and exits with exit code 0
 func (util *Util) LogAndExit(messages ...interface{}) {
+	simplelogger.FlushAll()
 	fmt.Println(messages...)
 	os.Exit(0)
 }
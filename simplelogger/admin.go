@@ -0,0 +1,153 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// AdminHandler is an http.Handler which lists the registered loggers (GET) and
+// changes them at runtime (POST). A POST takes the form values "spec" (a DEBUG
+// specification for all loggers) or "prefix" together with "enabled" and/or
+// "level", which are applied to the whole namespace of the prefix.
+type AdminHandler struct {
+	Registry *Registry
+}
+
+// LoggerStatus describes the state of a registered logger
+type LoggerStatus struct {
+	Enabled bool   `json:"enabled"`
+	Level   string `json:"level"`
+	Prefix  string `json:"prefix"`
+}
+
+// NewAdminHandler returns a new instance of AdminHandler for the registry
+// (DefaultRegistry if nil)
+func NewAdminHandler(registry *Registry) *AdminHandler {
+	if registry == nil {
+		registry = DefaultRegistry
+	}
+
+	return &AdminHandler{Registry: registry}
+}
+
+// Status returns the state of all registered loggers, one per prefix
+func (registry *Registry) Status() []LoggerStatus {
+	var (
+		statuses []LoggerStatus
+		seen     = map[string]bool{}
+	)
+
+	for _, logger := range registry.Loggers() {
+		if seen[logger.Prefix] {
+			continue
+		}
+		seen[logger.Prefix] = true
+
+		statuses = append(statuses, LoggerStatus{
+			Enabled: logger.IsEnabled(),
+			Level:   logger.GetLevel().String(),
+			Prefix:  logger.Prefix,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Prefix < statuses[j].Prefix
+	})
+
+	return statuses
+}
+
+func (handler *AdminHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut:
+		if updateError := handler.update(request); updateError != nil {
+			http.Error(writer, updateError.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		writer.Header().Set("Allow", "GET, POST, PUT")
+		http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(handler.Registry.Status())
+}
+
+func (handler *AdminHandler) update(request *http.Request) error {
+	if parseError := request.ParseForm(); parseError != nil {
+		return parseError
+	}
+
+	if request.Form.Has("spec") {
+		if _, parseError := ParseMatcher(request.Form.Get("spec")); parseError != nil {
+			return parseError
+		}
+		handler.Registry.Configure(request.Form.Get("spec"))
+		return nil
+	}
+
+	prefix := request.Form.Get("prefix")
+	if prefix == "" {
+		return fmt.Errorf("Either \"spec\" or \"prefix\" is required")
+	}
+
+	if len(handler.Registry.Subtree(prefix)) == 0 {
+		return fmt.Errorf("No logger found for \"%s\"", prefix)
+	}
+
+	var (
+		enabled bool
+		level   Level
+	)
+
+	if request.Form.Has("level") {
+		var parseError error
+		if level, parseError = ParseLevel(request.Form.Get("level")); parseError != nil {
+			return parseError
+		}
+	}
+
+	if request.Form.Has("enabled") {
+		var parseError error
+		if enabled, parseError = strconv.ParseBool(request.Form.Get("enabled")); parseError != nil {
+			return fmt.Errorf("Invalid value \"%s\" for \"enabled\"", request.Form.Get("enabled"))
+		}
+	}
+
+	// the loggers are only changed after all values have been validated
+	if request.Form.Has("level") {
+		handler.Registry.SetLevel(prefix, level)
+	}
+
+	if request.Form.Has("enabled") {
+		if enabled {
+			handler.Registry.Enable(prefix)
+		} else {
+			handler.Registry.Disable(prefix)
+		}
+	}
+
+	return nil
+}
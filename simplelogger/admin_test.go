@@ -0,0 +1,152 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+)
+
+// newAdminRegistry returns a registry with the loggers "adm" and "adm/child"
+func newAdminRegistry(t *testing.T) (*simplelogger.Registry, *simplelogger.SimpleLogger, *simplelogger.SimpleLogger) {
+	t.Helper()
+
+	logger := simplelogger.New("adm", false, false)
+	child := logger.Extend("child")
+	t.Cleanup(func() { simplelogger.Unregister(logger) })
+
+	registry := simplelogger.NewRegistry()
+	registry.Register(logger)
+	registry.Register(child)
+
+	return registry, logger, child
+}
+
+func serveAdmin(t *testing.T, registry *simplelogger.Registry, method string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := httptest.NewRequest(method, "/debug/loggers", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response := httptest.NewRecorder()
+	simplelogger.NewAdminHandler(registry).ServeHTTP(response, request)
+
+	return response
+}
+
+func TestAdminHandlerGet(t *testing.T) {
+	registry, logger, _ := newAdminRegistry(t)
+	logger.SetLevel(simplelogger.LevelWarn)
+
+	response := serveAdmin(t, registry, http.MethodGet, nil)
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", response.Code, response.Body)
+	}
+	if contentType := response.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+
+	var statuses []simplelogger.LoggerStatus
+	if decodeError := json.NewDecoder(response.Body).Decode(&statuses); decodeError != nil {
+		t.Fatal(decodeError)
+	}
+
+	expected := []simplelogger.LoggerStatus{
+		{Enabled: false, Level: "warn", Prefix: "adm"},
+		{Enabled: false, Level: "warn", Prefix: "adm/child"},
+	}
+	if len(statuses) != len(expected) || statuses[0] != expected[0] || statuses[1] != expected[1] {
+		t.Errorf("expected %v, got %v", expected, statuses)
+	}
+}
+
+func TestAdminHandlerPostPrefix(t *testing.T) {
+	registry, logger, child := newAdminRegistry(t)
+
+	response := serveAdmin(t, registry, http.MethodPost, url.Values{"prefix": {"adm"}, "enabled": {"true"}, "level": {"error"}})
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", response.Code, response.Body)
+	}
+
+	for _, namespaceLogger := range []*simplelogger.SimpleLogger{logger, child} {
+		if !namespaceLogger.IsEnabled() || namespaceLogger.GetLevel() != simplelogger.LevelError {
+			t.Errorf("%s was not updated", namespaceLogger.Prefix)
+		}
+	}
+
+	response = serveAdmin(t, registry, http.MethodPut, url.Values{"prefix": {"adm/child"}, "enabled": {"false"}})
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", response.Code, response.Body)
+	}
+	if child.IsEnabled() || !logger.IsEnabled() {
+		t.Error("only the child should be disabled")
+	}
+}
+
+func TestAdminHandlerPostSpec(t *testing.T) {
+	registry, logger, child := newAdminRegistry(t)
+
+	response := serveAdmin(t, registry, http.MethodPost, url.Values{"spec": {"adm*=info,-adm/child"}})
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", response.Code, response.Body)
+	}
+
+	if !logger.IsEnabled() || logger.GetLevel() != simplelogger.LevelInfo {
+		t.Error("the spec was not applied to adm")
+	}
+	if child.IsEnabled() {
+		t.Error("the spec was not applied to adm/child")
+	}
+}
+
+func TestAdminHandlerErrors(t *testing.T) {
+	testCases := []struct {
+		form   url.Values
+		method string
+		status int
+	}{
+		{form: url.Values{"spec": {"adm=loud"}}, method: http.MethodPost, status: http.StatusBadRequest},
+		{form: url.Values{}, method: http.MethodPost, status: http.StatusBadRequest},
+		{form: url.Values{"prefix": {"unknown"}, "enabled": {"true"}}, method: http.MethodPost, status: http.StatusBadRequest},
+		{form: url.Values{"prefix": {"adm"}, "level": {"loud"}}, method: http.MethodPost, status: http.StatusBadRequest},
+		{form: url.Values{"prefix": {"adm"}, "level": {"error"}, "enabled": {"maybe"}}, method: http.MethodPost, status: http.StatusBadRequest},
+		{form: url.Values{"prefix": {"adm"}, "enabled": {"true"}}, method: http.MethodDelete, status: http.StatusMethodNotAllowed},
+	}
+
+	for _, testCase := range testCases {
+		registry, logger, child := newAdminRegistry(t)
+
+		response := serveAdmin(t, registry, testCase.method, testCase.form)
+		if response.Code != testCase.status {
+			t.Errorf("%s %v: expected status %d, got %d", testCase.method, testCase.form, testCase.status, response.Code)
+		}
+
+		// a rejected request must not change any logger
+		for _, namespaceLogger := range []*simplelogger.SimpleLogger{logger, child} {
+			if namespaceLogger.IsEnabled() || namespaceLogger.GetLevel() != simplelogger.LevelTrace {
+				t.Errorf("%s %v changed %s", testCase.method, testCase.form, namespaceLogger.Prefix)
+			}
+		}
+	}
+}
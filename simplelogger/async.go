@@ -0,0 +1,241 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what happens when the queue of an asynchronous logger is full
+type OverflowPolicy int
+
+// The available overflow policies
+const (
+	// OverflowBlock waits until there is space in the queue
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the entry which is logged
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest entry in the queue
+	OverflowDropOldest
+)
+
+// asyncQueues are all open queues, so that FlushAll also reaches loggers which
+// aren't registered (e.g. created as struct literal)
+var (
+	asyncQueues      = map[*asyncQueue]bool{}
+	asyncQueuesMutex sync.Mutex
+)
+
+// asyncQueue is a bounded queue with a background goroutine which writes the entries
+type asyncQueue struct {
+	closed  bool
+	done    chan struct{}
+	dropped atomic.Int64
+	items   chan asyncItem
+	mutex   sync.RWMutex
+	policy  OverflowPolicy
+}
+
+// asyncItem is an entry to write or, if flushed is set, a flush request
+type asyncItem struct {
+	entry   *Entry
+	flushed chan struct{}
+	logger  *SimpleLogger
+}
+
+// SetAsync makes the logger and its children write in the background. Entries
+// are queued (at most queueSize) and the policy decides what happens if the
+// queue is full. Use Flush or Close before the program exits.
+func (logger *SimpleLogger) SetAsync(queueSize int, policy OverflowPolicy) {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	queue := &asyncQueue{
+		done:   make(chan struct{}),
+		items:  make(chan asyncItem, queueSize),
+		policy: policy,
+	}
+
+	go queue.run()
+
+	asyncQueuesMutex.Lock()
+	asyncQueues[queue] = true
+	asyncQueuesMutex.Unlock()
+
+	logger.mutex.Lock()
+	previousQueue := logger.async
+	logger.async = queue
+	logger.mutex.Unlock()
+
+	if previousQueue != nil {
+		previousQueue.close()
+	}
+}
+
+// asyncQueue returns the queue of the logger or of its nearest asynchronous parent
+func (logger *SimpleLogger) asyncQueue() *asyncQueue {
+	for current := logger; current != nil; current = current.parent {
+		current.mutex.RLock()
+		queue := current.async
+		current.mutex.RUnlock()
+
+		if queue != nil {
+			return queue
+		}
+	}
+
+	return nil
+}
+
+// Flush waits until all queued entries of an asynchronous logger are written
+func (logger *SimpleLogger) Flush() {
+	if queue := logger.asyncQueue(); queue != nil {
+		queue.flush()
+	}
+}
+
+// Close writes all queued entries and stops writing in the background.
+// Entries logged afterwards are written directly.
+func (logger *SimpleLogger) Close() error {
+	logger.mutex.Lock()
+	queue := logger.async
+	logger.async = nil
+	logger.mutex.Unlock()
+
+	if queue != nil {
+		queue.close()
+	}
+
+	return nil
+}
+
+// Dropped returns how many entries were dropped because the queue was full
+func (logger *SimpleLogger) Dropped() int64 {
+	if queue := logger.asyncQueue(); queue != nil {
+		return queue.dropped.Load()
+	}
+
+	return 0
+}
+
+func (queue *asyncQueue) run() {
+	defer close(queue.done)
+
+	for item := range queue.items {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+
+		item.logger.emitNow(item.entry)
+	}
+}
+
+// enqueue queues the entry and returns false if the queue is closed
+func (queue *asyncQueue) enqueue(logger *SimpleLogger, entry *Entry) bool {
+	queue.mutex.RLock()
+	defer queue.mutex.RUnlock()
+
+	if queue.closed {
+		return false
+	}
+
+	item := asyncItem{entry: entry, logger: logger}
+
+	switch queue.policy {
+	case OverflowDropNewest:
+		select {
+		case queue.items <- item:
+		default:
+			queue.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case queue.items <- item:
+				return true
+			default:
+			}
+
+			select {
+			case oldestItem := <-queue.items:
+				if oldestItem.flushed != nil {
+					// flush requests are never dropped, only moved to the end
+					queue.items <- oldestItem
+				} else {
+					queue.dropped.Add(1)
+				}
+			default:
+			}
+		}
+	default:
+		queue.items <- item
+	}
+
+	return true
+}
+
+func (queue *asyncQueue) flush() {
+	queue.mutex.RLock()
+
+	if queue.closed {
+		queue.mutex.RUnlock()
+		return
+	}
+
+	flushed := make(chan struct{})
+	queue.items <- asyncItem{flushed: flushed}
+	queue.mutex.RUnlock()
+
+	<-flushed
+}
+
+func (queue *asyncQueue) close() {
+	queue.mutex.Lock()
+
+	if queue.closed {
+		queue.mutex.Unlock()
+		return
+	}
+
+	queue.closed = true
+	close(queue.items)
+	queue.mutex.Unlock()
+
+	asyncQueuesMutex.Lock()
+	delete(asyncQueues, queue)
+	asyncQueuesMutex.Unlock()
+
+	<-queue.done
+}
+
+// FlushAll flushes all asynchronous loggers, registered or not, e.g. before os.Exit
+func FlushAll() {
+	asyncQueuesMutex.Lock()
+	queues := make([]*asyncQueue, 0, len(asyncQueues))
+	for queue := range asyncQueues {
+		queues = append(queues, queue)
+	}
+	asyncQueuesMutex.Unlock()
+
+	for _, queue := range queues {
+		queue.flush()
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+)
+
+// gatedSink is a sink which blocks every write until the gate is opened and
+// reports on started when a write begins
+type gatedSink struct {
+	entrySink
+
+	gate    chan struct{}
+	once    sync.Once
+	started chan string
+}
+
+func newGatedSink() *gatedSink {
+	return &gatedSink{gate: make(chan struct{}), started: make(chan string, 100)}
+}
+
+func (sink *gatedSink) WriteEntry(entry *simplelogger.Entry) error {
+	sink.started <- entry.Message
+	<-sink.gate
+	return sink.entrySink.WriteEntry(entry)
+}
+
+func (sink *gatedSink) open() {
+	sink.once.Do(func() { close(sink.gate) })
+}
+
+// waitStarted waits until the background writer is writing the message
+func (sink *gatedSink) waitStarted(t *testing.T, message string) {
+	t.Helper()
+
+	select {
+	case startedMessage := <-sink.started:
+		if startedMessage != message {
+			t.Fatalf("expected %q to be written, got %q", message, startedMessage)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%q was not written", message)
+	}
+}
+
+func newAsyncLogger(t *testing.T, sink simplelogger.Sink, queueSize int, policy simplelogger.OverflowPolicy) *simplelogger.SimpleLogger {
+	t.Helper()
+
+	logger := &simplelogger.SimpleLogger{Enabled: true, Level: simplelogger.LevelTrace, Prefix: "async", Sinks: []simplelogger.Sink{sink}}
+	logger.SetAsync(queueSize, policy)
+	t.Cleanup(func() { logger.Close() })
+
+	return logger
+}
+
+func expectMessages(t *testing.T, sink *entrySink, expected ...string) {
+	t.Helper()
+
+	if messages := sink.messages(); fmt.Sprint(messages) != fmt.Sprint(expected) {
+		t.Errorf("expected messages %q, got %q", expected, messages)
+	}
+}
+
+func TestAsyncOverflowBlock(t *testing.T) {
+	sink := newGatedSink()
+	logger := newAsyncLogger(t, sink, 1, simplelogger.OverflowBlock)
+	defer sink.open()
+
+	logger.Info("1")
+	sink.waitStarted(t, "1")
+	logger.Info("2")
+
+	logged := make(chan struct{})
+	go func() {
+		logger.Info("3")
+		close(logged)
+	}()
+
+	select {
+	case <-logged:
+		t.Fatal("logging to a full queue did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	sink.open()
+	<-logged
+	logger.Flush()
+
+	expectMessages(t, &sink.entrySink, "1", "2", "3")
+	if dropped := logger.Dropped(); dropped != 0 {
+		t.Errorf("%d entries were dropped", dropped)
+	}
+}
+
+func TestAsyncOverflowDropNewest(t *testing.T) {
+	sink := newGatedSink()
+	logger := newAsyncLogger(t, sink, 1, simplelogger.OverflowDropNewest)
+	defer sink.open()
+
+	logger.Info("1")
+	sink.waitStarted(t, "1")
+	logger.Info("2")
+	logger.Info("3")
+	logger.Info("4")
+
+	sink.open()
+	logger.Flush()
+
+	expectMessages(t, &sink.entrySink, "1", "2")
+	if dropped := logger.Dropped(); dropped != 2 {
+		t.Errorf("expected 2 dropped entries, got %d", dropped)
+	}
+}
+
+func TestAsyncOverflowDropOldest(t *testing.T) {
+	sink := newGatedSink()
+	logger := newAsyncLogger(t, sink, 2, simplelogger.OverflowDropOldest)
+	defer sink.open()
+
+	logger.Info("1")
+	sink.waitStarted(t, "1")
+	for _, message := range []string{"2", "3", "4", "5"} {
+		logger.Info(message)
+	}
+
+	sink.open()
+	logger.Flush()
+
+	expectMessages(t, &sink.entrySink, "1", "4", "5")
+	if dropped := logger.Dropped(); dropped != 2 {
+		t.Errorf("expected 2 dropped entries, got %d", dropped)
+	}
+}
+
+func TestAsyncDropOldestKeepsFlush(t *testing.T) {
+	sink := newGatedSink()
+	logger := newAsyncLogger(t, sink, 2, simplelogger.OverflowDropOldest)
+	defer sink.open()
+
+	logger.Info("1")
+	sink.waitStarted(t, "1")
+
+	flushed := make(chan struct{})
+	go func() {
+		logger.Flush()
+		close(flushed)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// the flush request is moved to the end of the full queue instead of
+	// being dropped with the oldest entries
+	for index := 2; index <= 6; index++ {
+		logger.Info(fmt.Sprint(index))
+	}
+
+	select {
+	case <-flushed:
+		t.Fatal("Flush returned before the queue was written")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	sink.open()
+
+	select {
+	case <-flushed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the flush request was lost")
+	}
+
+	logger.Flush()
+
+	messages := sink.messages()
+	if len(messages) < 2 || messages[0] != "1" || messages[len(messages)-1] != "6" {
+		t.Errorf("unexpected messages %q", messages)
+	}
+	if dropped := logger.Dropped(); int(dropped)+len(messages) != 6 {
+		t.Errorf("%d written and %d dropped entries don't add up to 6", len(messages), dropped)
+	}
+}
+
+func TestAsyncFlushOrder(t *testing.T) {
+	sink := &entrySink{}
+	logger := newAsyncLogger(t, sink, 100, simplelogger.OverflowBlock)
+	child := logger.Extend("child")
+
+	var expected []string
+	for index := 0; index < 50; index++ {
+		message := fmt.Sprint(index)
+		expected = append(expected, message)
+		if index%2 == 0 {
+			logger.Info(message)
+		} else {
+			child.Info(message)
+		}
+	}
+
+	child.Flush()
+
+	expectMessages(t, sink, expected...)
+}
+
+func TestAsyncClose(t *testing.T) {
+	sink := &entrySink{}
+	logger := newAsyncLogger(t, sink, 100, simplelogger.OverflowBlock)
+
+	logger.Info("1")
+	logger.Info("2")
+
+	if closeError := logger.Close(); closeError != nil {
+		t.Fatal(closeError)
+	}
+	expectMessages(t, sink, "1", "2")
+
+	// after Close, entries are written directly and Flush returns at once
+	logger.Info("3")
+	expectMessages(t, sink, "1", "2", "3")
+	logger.Flush()
+
+	if closeError := logger.Close(); closeError != nil {
+		t.Errorf("closing twice failed: %s", closeError)
+	}
+}
+
+func TestAsyncSetAsyncReplacesQueue(t *testing.T) {
+	sink := &entrySink{}
+	logger := newAsyncLogger(t, sink, 100, simplelogger.OverflowBlock)
+
+	logger.Info("1")
+	logger.SetAsync(10, simplelogger.OverflowDropNewest)
+	expectMessages(t, sink, "1")
+
+	logger.Info("2")
+	logger.Flush()
+	expectMessages(t, sink, "1", "2")
+}
+
+func TestFlushAll(t *testing.T) {
+	sink := newGatedSink()
+	logger := newAsyncLogger(t, sink, 10, simplelogger.OverflowBlock)
+
+	// the logger isn't registered, FlushAll has to wait for its queue anyway
+	logger.Info("1")
+	logger.Info("2")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		sink.open()
+	}()
+
+	simplelogger.FlushAll()
+
+	expectMessages(t, &sink.entrySink, "1", "2")
+}
@@ -0,0 +1,204 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// maxStackDepth is the maximum number of frames in a stack trace
+const maxStackDepth = 32
+
+// Caller is the location in the source code where a message was logged
+type Caller struct {
+	File     string
+	Function string
+	Line     int
+
+	pc uintptr
+}
+
+// String returns the caller in the form "file.go:42 package.Function"
+func (caller *Caller) String() string {
+	return fmt.Sprintf("%s:%d %s", filepath.Base(caller.File), caller.Line, caller.Function)
+}
+
+// callerFromPC returns the caller for the program counter or nil if it is unknown
+func callerFromPC(pc uintptr) *Caller {
+	if pc == 0 {
+		return nil
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	caller := frameToCaller(frame)
+	caller.pc = pc
+	return caller
+}
+
+func frameToCaller(frame runtime.Frame) *Caller {
+	function := frame.Function
+	if index := strings.LastIndex(function, "/"); index != -1 {
+		function = function[index+1:]
+	}
+
+	return &Caller{
+		File:     frame.File,
+		Function: function,
+		Line:     frame.Line,
+	}
+}
+
+// captureStack returns the stack of the current goroutine, skipping the given
+// number of frames (0 is the caller of captureStack)
+func captureStack(skip int) []Caller {
+	pcs := make([]uintptr, maxStackDepth)
+	count := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:count])
+
+	var stack []Caller
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			stack = append(stack, *frameToCaller(frame))
+		}
+		if !more {
+			break
+		}
+	}
+
+	return stack
+}
+
+// errorsFromArgs returns all errors in the arguments of a log call
+func errorsFromArgs(args []interface{}) []error {
+	var errs []error
+
+	for _, arg := range args {
+		if err, isError := arg.(error); isError && err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// errorText returns the message of the error like fmt does: "<nil>" for a nil
+// pointer and a placeholder if the Error method panics
+func errorText(err error) (text string) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if value := reflect.ValueOf(err); value.Kind() == reflect.Pointer && value.IsNil() {
+				text = "<nil>"
+				return
+			}
+			text = fmt.Sprintf("%%!v(PANIC=Error method: %v)", recovered)
+		}
+	}()
+
+	return err.Error()
+}
+
+// unwrapAll returns the errors wrapped by err with errors.Unwrap or errors.Join.
+// Errors whose Unwrap method panics (e.g. on a nil pointer) wrap nothing.
+func unwrapAll(err error) (causes []error) {
+	defer func() {
+		if recover() != nil {
+			causes = nil
+		}
+	}()
+
+	if joinedError, isJoined := err.(interface{ Unwrap() []error }); isJoined {
+		return joinedError.Unwrap()
+	}
+
+	if wrappedError := errors.Unwrap(err); wrappedError != nil {
+		return []error{wrappedError}
+	}
+
+	return nil
+}
+
+// errorCause is an error wrapped by a logged error, Depth is 1 for its direct causes
+type errorCause struct {
+	Depth int
+	Text  string
+}
+
+// errorCauses returns the causes of the errors in the order of a depth-first walk
+func errorCauses(errs []error) []errorCause {
+	var causes []errorCause
+
+	var walk func(err error, depth int)
+	walk = func(err error, depth int) {
+		for _, cause := range unwrapAll(err) {
+			causes = append(causes, errorCause{Depth: depth, Text: errorText(cause)})
+			walk(cause, depth+1)
+		}
+	}
+
+	for _, err := range errs {
+		walk(err, 1)
+	}
+
+	return causes
+}
+
+// causeTexts returns the messages of the causes
+func causeTexts(causes []errorCause) []string {
+	texts := make([]string, 0, len(causes))
+
+	for _, cause := range causes {
+		texts = append(texts, cause.Text)
+	}
+
+	return texts
+}
+
+// stackLines returns the stack as lines in the form of runtime/debug.Stack
+func stackLines(stack []Caller) []string {
+	lines := make([]string, 0, len(stack)*2)
+
+	for _, frame := range stack {
+		lines = append(lines, frame.Function, fmt.Sprintf("\t%s:%d", frame.File, frame.Line))
+	}
+
+	return lines
+}
+
+// writeDetails writes the error causes and the stack of the entry as indented
+// lines below the message
+func writeDetails(buffer *bytes.Buffer, entry *Entry) {
+	for _, cause := range errorCauses(entry.Errors) {
+		indentation := strings.Repeat("  ", cause.Depth+1)
+		buffer.WriteString("\n" + indentation + "caused by: ")
+		buffer.WriteString(strings.ReplaceAll(cause.Text, "\n", "\n"+indentation+"           "))
+	}
+
+	if len(entry.Stack) > 0 {
+		buffer.WriteString("\n  stack:")
+		for _, line := range stackLines(entry.Stack) {
+			buffer.WriteString("\n    " + line)
+		}
+	}
+}
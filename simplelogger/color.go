@@ -0,0 +1,146 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorLevel describes which colours a writer supports
+type ColorLevel int
+
+// The available colour levels
+const (
+	ColorNone ColorLevel = iota
+	ColorBasic
+	Color256
+)
+
+// basicColors are the ANSI colours for namespaces on terminals with basic colours
+var basicColors = []int{6, 2, 3, 4, 5, 1}
+
+// extendedColors are the colours for namespaces on terminals with 256 colours,
+// chosen to be readable on dark and light backgrounds
+var extendedColors = []int{
+	20, 21, 26, 27, 32, 33, 38, 39, 40, 41, 42, 43, 44, 45, 56, 57, 62, 63, 68,
+	69, 74, 75, 76, 77, 78, 79, 80, 81, 92, 93, 98, 99, 112, 113, 128, 129, 134,
+	135, 148, 149, 160, 161, 162, 163, 164, 165, 166, 167, 168, 169, 170, 171,
+	172, 173, 178, 179, 184, 185, 196, 197, 198, 199, 200, 201, 202, 203, 204,
+	205, 206, 207, 208, 209, 214, 215, 220, 221,
+}
+
+// fileDescriptor is implemented by *os.File and other writers backed by a file
+type fileDescriptor interface {
+	Stat() (os.FileInfo, error)
+}
+
+// DetectColor returns the colour level of the writer. Colours are disabled if
+// the writer is not a terminal, NO_COLOR is set or TERM is "dumb". A non-empty
+// FORCE_COLOR turns them on regardless (FORCE_COLOR=0 turns them off,
+// FORCE_COLOR=2 or 3 forces 256 colours).
+func DetectColor(writer io.Writer) ColorLevel {
+	if forceColor, isSet := os.LookupEnv("FORCE_COLOR"); isSet {
+		switch strings.ToLower(forceColor) {
+		case "0", "false":
+			return ColorNone
+		case "2", "3":
+			return Color256
+		default:
+			return ColorBasic
+		}
+	}
+
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return ColorNone
+	}
+
+	if !isTerminal(writer) {
+		return ColorNone
+	}
+
+	colorTerm := strings.ToLower(os.Getenv("COLORTERM"))
+	if strings.Contains(os.Getenv("TERM"), "256color") || colorTerm == "truecolor" || colorTerm == "24bit" {
+		return Color256
+	}
+
+	return ColorBasic
+}
+
+func isTerminal(writer io.Writer) bool {
+	file, isFile := writer.(fileDescriptor)
+	if !isFile {
+		return false
+	}
+
+	fileInfo, statError := file.Stat()
+	if statError != nil {
+		return false
+	}
+
+	return fileInfo.Mode()&os.ModeCharDevice != 0
+}
+
+func paint(color ColorLevel, code string, message string) string {
+	if color == ColorNone {
+		return message
+	}
+
+	return fmt.Sprintf("\033[%sm%s\033[0m", code, message)
+}
+
+// namespaceHash returns the same hash for a namespace as debug.js does, so a
+// namespace gets the same colour in both
+func namespaceHash(namespace string) int {
+	var hash int32
+
+	for _, character := range namespace {
+		hash = (hash << 5) - hash + int32(character)
+	}
+
+	if hash < 0 {
+		return -int(hash)
+	}
+
+	return int(hash)
+}
+
+// namespaceColor returns the escape code of a stable colour for the namespace
+func namespaceColor(color ColorLevel, namespace string) string {
+	hash := namespaceHash(namespace)
+
+	if color == Color256 {
+		return fmt.Sprintf("38;5;%d", extendedColors[hash%len(extendedColors)])
+	}
+
+	return fmt.Sprintf("3%d", basicColors[hash%len(basicColors)])
+}
+
+func bold(color ColorLevel, message string) string {
+	return paint(color, "1", message)
+}
+
+func red(color ColorLevel, message string) string {
+	return paint(color, "91", message)
+}
+
+func yellow(color ColorLevel, message string) string {
+	return paint(color, "93", message)
+}
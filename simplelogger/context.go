@@ -0,0 +1,127 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import "context"
+
+type contextKey int
+
+const (
+	loggerContextKey contextKey = iota
+	fieldsContextKey
+)
+
+// NewContext returns a copy of the context which carries the logger
+func NewContext(ctx context.Context, logger *SimpleLogger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, logger)
+}
+
+// FromContext returns the logger of the context or nil if there is none
+func FromContext(ctx context.Context) *SimpleLogger {
+	if ctx == nil {
+		return nil
+	}
+
+	logger, _ := ctx.Value(loggerContextKey).(*SimpleLogger)
+	return logger
+}
+
+// ContextWithFields returns a copy of the context which carries the key/value
+// pairs in addition to the fields already stored on the context. They are
+// added to every message logged with one of the *Context methods.
+func ContextWithFields(ctx context.Context, keyvals ...interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	fields := append(append([]Field{}, ContextFields(ctx)...), toFields(keyvals)...)
+	return context.WithValue(ctx, fieldsContextKey, fields)
+}
+
+// ContextFields returns the fields stored on the context
+func ContextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(fieldsContextKey).([]Field)
+	return fields
+}
+
+// contextKeyvals prepends the fields of the context to the key/value pairs
+func contextKeyvals(ctx context.Context, keyvals []interface{}) []interface{} {
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return keyvals
+	}
+
+	contextKeyvals := make([]interface{}, 0, len(fields)+len(keyvals))
+	for _, field := range fields {
+		contextKeyvals = append(contextKeyvals, field)
+	}
+
+	return append(contextKeyvals, keyvals...)
+}
+
+// LogContext logs one or more unformatted messages and the fields of the
+// context with debug level if the logger is enabled
+func (logger *SimpleLogger) LogContext(ctx context.Context, messages ...interface{}) {
+	logger.output(LevelDebug, unformatted(messages), contextKeyvals(ctx, nil))
+}
+
+// LogfContext logs one or more formatted messages and the fields of the context
+// with debug level if the logger is enabled
+func (logger *SimpleLogger) LogfContext(ctx context.Context, format string, messages ...interface{}) {
+	logger.output(LevelDebug, formatted(format, messages), contextKeyvals(ctx, nil))
+}
+
+// TraceContext logs a message, the fields of the context and optional
+// key/value pairs with trace level
+func (logger *SimpleLogger) TraceContext(ctx context.Context, message string, keyvals ...interface{}) {
+	logger.output(LevelTrace, plain(message), contextKeyvals(ctx, keyvals))
+}
+
+// DebugContext logs a message, the fields of the context and optional
+// key/value pairs with debug level
+func (logger *SimpleLogger) DebugContext(ctx context.Context, message string, keyvals ...interface{}) {
+	logger.output(LevelDebug, plain(message), contextKeyvals(ctx, keyvals))
+}
+
+// InfoContext logs a message, the fields of the context and optional key/value
+// pairs with info level
+func (logger *SimpleLogger) InfoContext(ctx context.Context, message string, keyvals ...interface{}) {
+	logger.output(LevelInfo, plain(message), contextKeyvals(ctx, keyvals))
+}
+
+// WarnContext logs a message, the fields of the context and optional key/value
+// pairs with warn level to ErrOut
+func (logger *SimpleLogger) WarnContext(ctx context.Context, message string, keyvals ...interface{}) {
+	logger.output(LevelWarn, plain(message), contextKeyvals(ctx, keyvals))
+}
+
+// ErrorContext logs one or more unformatted messages and the fields of the
+// context with error level to ErrOut if the logger is enabled
+func (logger *SimpleLogger) ErrorContext(ctx context.Context, messages ...interface{}) {
+	logger.output(LevelError, unformatted(messages), contextKeyvals(ctx, nil))
+}
+
+// ErrorfContext logs one or more formatted messages and the fields of the
+// context with error level to ErrOut if the logger is enabled
+func (logger *SimpleLogger) ErrorfContext(ctx context.Context, format string, messages ...interface{}) {
+	logger.output(LevelError, formatted(format, messages), contextKeyvals(ctx, nil))
+}
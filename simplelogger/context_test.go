@@ -0,0 +1,157 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+	"github.com/ffflorian/go-tools/simplelogger/loggertest"
+)
+
+// fieldKeys returns the keys and values of the fields as "key=value"
+func fieldKeys(fields []simplelogger.Field) []string {
+	var keys []string
+	for _, field := range fields {
+		keys = append(keys, fmt.Sprintf("%s=%v", field.Key, field.Value))
+	}
+	return keys
+}
+
+func expectFields(t *testing.T, entry simplelogger.Entry, expected ...string) {
+	t.Helper()
+
+	keys := fieldKeys(entry.Fields)
+	if fmt.Sprint(keys) != fmt.Sprint(expected) {
+		t.Errorf("%q: expected fields %v, got %v", entry.Message, expected, keys)
+	}
+}
+
+func newContextLogger(t *testing.T) (*simplelogger.SimpleLogger, *loggertest.Recorder) {
+	t.Helper()
+
+	logger := simplelogger.New("ctx", true, false)
+	t.Cleanup(func() { simplelogger.Unregister(logger) })
+	logger.Sinks = []simplelogger.Sink{&entrySink{}}
+
+	return logger, loggertest.Record(t, logger)
+}
+
+func TestNewContext(t *testing.T) {
+	logger, _ := newContextLogger(t)
+
+	if simplelogger.FromContext(nil) != nil || simplelogger.FromContext(context.Background()) != nil {
+		t.Error("a context without a logger returned one")
+	}
+
+	ctx := simplelogger.NewContext(context.Background(), logger)
+	if simplelogger.FromContext(ctx) != logger {
+		t.Error("the logger was not stored on the context")
+	}
+
+	child := logger.Extend("child")
+	childCtx := simplelogger.NewContext(ctx, child)
+	if simplelogger.FromContext(childCtx) != child || simplelogger.FromContext(ctx) != logger {
+		t.Error("a derived context changed the logger of its parent")
+	}
+}
+
+func TestContextWithFields(t *testing.T) {
+	if fields := simplelogger.ContextFields(nil); fields != nil {
+		t.Errorf("unexpected fields %v", fields)
+	}
+
+	ctx := simplelogger.ContextWithFields(nil, "request", 1)
+	firstCtx := simplelogger.ContextWithFields(ctx, "path", "/a")
+	secondCtx := simplelogger.ContextWithFields(ctx, "path", "/b")
+
+	if keys := fieldKeys(simplelogger.ContextFields(ctx)); fmt.Sprint(keys) != "[request=1]" {
+		t.Errorf("a derived context changed the fields of its parent: %v", keys)
+	}
+	if keys := fieldKeys(simplelogger.ContextFields(firstCtx)); fmt.Sprint(keys) != "[request=1 path=/a]" {
+		t.Errorf("unexpected fields %v", keys)
+	}
+	if keys := fieldKeys(simplelogger.ContextFields(secondCtx)); fmt.Sprint(keys) != "[request=1 path=/b]" {
+		t.Errorf("unexpected fields %v", keys)
+	}
+}
+
+func TestContextMethods(t *testing.T) {
+	logger, recorder := newContextLogger(t)
+	ctx := simplelogger.ContextWithFields(context.Background(), "request", 1)
+	ctx = simplelogger.NewContext(ctx, logger.With("component", "git"))
+
+	contextLogger := simplelogger.FromContext(ctx)
+	contextLogger.TraceContext(ctx, "trace", "key", "value")
+	contextLogger.DebugContext(ctx, "debug")
+	contextLogger.InfoContext(ctx, "info", "key", "value")
+	contextLogger.WarnContext(ctx, "warn")
+	contextLogger.LogContext(ctx, "log")
+	contextLogger.LogfContext(ctx, "logf %d", 1)
+	contextLogger.ErrorContext(ctx, "error")
+	contextLogger.ErrorfContext(ctx, "errorf %d", 1)
+	contextLogger.InfoContext(context.Background(), "without fields")
+
+	entries := recorder.All()
+	if len(entries) != 9 {
+		t.Fatalf("expected 9 entries, got %d", len(entries))
+	}
+
+	expectFields(t, entries[0], "component=git", "request=1", "key=value")
+	expectFields(t, entries[2], "component=git", "request=1", "key=value")
+	for _, index := range []int{1, 3, 4, 5, 6, 7} {
+		expectFields(t, entries[index], "component=git", "request=1")
+	}
+	expectFields(t, entries[8], "component=git")
+
+	expectedLevels := []simplelogger.Level{
+		simplelogger.LevelTrace, simplelogger.LevelDebug, simplelogger.LevelInfo, simplelogger.LevelWarn,
+		simplelogger.LevelDebug, simplelogger.LevelDebug, simplelogger.LevelError, simplelogger.LevelError,
+	}
+	for index, level := range expectedLevels {
+		if entries[index].Level != level {
+			t.Errorf("%q: expected level %s, got %s", entries[index].Message, level, entries[index].Level)
+		}
+	}
+
+	if entries[5].Message != "logf 1" || entries[7].Message != "errorf 1" {
+		t.Errorf("unexpected formatted messages %q and %q", entries[5].Message, entries[7].Message)
+	}
+}
+
+func TestContextSlogHandler(t *testing.T) {
+	logger, recorder := newContextLogger(t)
+	slogger := slog.New(simplelogger.NewHandler(logger)).With("component", "git")
+	ctx := simplelogger.ContextWithFields(context.Background(), "request", 1)
+
+	slogger.InfoContext(ctx, "with context", "key", "value")
+	slogger.WithGroup("response").InfoContext(ctx, "grouped", "status", 200)
+	slogger.Info("without context")
+
+	entries := recorder.All()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	expectFields(t, entries[0], "request=1", "component=git", "key=value")
+	expectFields(t, entries[1], "request=1", "component=git", "response=[{status 200}]")
+	expectFields(t, entries[2], "component=git")
+}
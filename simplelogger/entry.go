@@ -0,0 +1,114 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"fmt"
+	"time"
+)
+
+// badKey is used as the key for a value without a key
+const badKey = "!BADKEY"
+
+// Field is a key/value pair attached to a log entry
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Group returns a field which groups the key/value pairs under the key
+func Group(key string, keyvals ...interface{}) Field {
+	return Field{Key: key, Value: toFields(keyvals)}
+}
+
+// Entry is a single log message. Color is the colour level of the writer the
+// entry is formatted for, Delta the time since the prefix logged the last time.
+// Caller and Stack are only set if the logger reports them, Errors contains
+// the errors passed to Error and Errorf.
+type Entry struct {
+	Caller  *Caller
+	Color   ColorLevel
+	Delta   time.Duration
+	Errors  []error
+	Fields  []Field
+	Level   Level
+	Message string
+	Prefix  string
+	Stack   []Caller
+	Time    time.Time
+}
+
+// toFields takes alternating keys and values (e.g. "repo", name, "url", url) and
+// returns them as fields. Arguments which already are a Field are used as is,
+// lazy values (func() interface{}) are resolved.
+func toFields(keyvals []interface{}) []Field {
+	var fields []Field
+
+	keyvals = resolveLazy(keyvals)
+
+	for index := 0; index < len(keyvals); index++ {
+		switch key := keyvals[index].(type) {
+		case Field:
+			fields = append(fields, key)
+		case string:
+			if index+1 == len(keyvals) {
+				fields = append(fields, Field{Key: badKey, Value: key})
+				continue
+			}
+			fields = append(fields, Field{Key: key, Value: keyvals[index+1]})
+			index++
+		default:
+			fields = append(fields, Field{Key: badKey, Value: key})
+		}
+	}
+
+	return fields
+}
+
+// flattenFields resolves groups into fields with dotted keys (e.g. "request.id")
+func flattenFields(prefix string, fields []Field) []Field {
+	var flatFields []Field
+
+	for _, field := range fields {
+		key := field.Key
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if groupFields, isGroup := field.Value.([]Field); isGroup {
+			flatFields = append(flatFields, flattenFields(key, groupFields)...)
+			continue
+		}
+
+		flatFields = append(flatFields, Field{Key: key, Value: field.Value})
+	}
+
+	return flatFields
+}
+
+// stringValue returns the value as it is displayed in text output
+func stringValue(value interface{}) string {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue
+	case error:
+		return errorText(typedValue)
+	default:
+		return fmt.Sprint(typedValue)
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatter turns a log entry into a line of output
+type Formatter interface {
+	Format(entry *Entry) []byte
+}
+
+// TextFormatter formats entries in the human readable format like debug.js,
+// with a coloured prefix and the time since the prefix logged the last time
+// (e.g. "+123ms")
+type TextFormatter struct{}
+
+// JSONFormatter formats entries as JSON lines
+type JSONFormatter struct{}
+
+// LogfmtFormatter formats entries as logfmt lines
+type LogfmtFormatter struct{}
+
+// FormatterByName returns the formatter for the name "text", "json" or "logfmt"
+func FormatterByName(name string) (Formatter, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "text":
+		return &TextFormatter{}, nil
+	case "json":
+		return &JSONFormatter{}, nil
+	case "logfmt":
+		return &LogfmtFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("Unknown log format \"%s\"", name)
+	}
+}
+
+// Format formats the entry in the human readable format
+func (formatter *TextFormatter) Format(entry *Entry) []byte {
+	var buffer bytes.Buffer
+
+	color := entry.Color
+
+	caller := ""
+	if entry.Caller != nil {
+		caller = paint(color, "2", "["+entry.Caller.String()+"]") + " "
+	}
+
+	message := strings.ReplaceAll(entry.Message, "\n", "\n"+strings.Repeat(" ", len(entry.Prefix)+1))
+
+	switch {
+	case entry.Level >= LevelError:
+		fmt.Fprintf(&buffer, "%s %s%s %s", bold(color, red(color, entry.Prefix)), caller, red(color, "Error:"), red(color, message))
+	case entry.Level == LevelWarn:
+		fmt.Fprintf(&buffer, "%s %s%s %s", bold(color, yellow(color, entry.Prefix)), caller, yellow(color, "Warning:"), message)
+	default:
+		prefixColor := namespaceColor(color, entry.Prefix)
+		fmt.Fprintf(&buffer, "%s %s%s", bold(color, paint(color, prefixColor, entry.Prefix)), caller, message)
+	}
+
+	for _, field := range flattenFields("", entry.Fields) {
+		buffer.WriteByte(' ')
+		writeLogfmtPair(&buffer, field.Key, stringValue(field.Value))
+	}
+
+	buffer.WriteString(" ")
+	buffer.WriteString(paint(color, namespaceColor(color, entry.Prefix), "+"+humanizeDuration(entry.Delta)))
+	writeDetails(&buffer, entry)
+	buffer.WriteByte('\n')
+	return buffer.Bytes()
+}
+
+// humanizeDuration returns the duration in a short form like "123ms" or "5s"
+func humanizeDuration(duration time.Duration) string {
+	switch {
+	case duration >= 24*time.Hour:
+		return fmt.Sprintf("%dd", duration/(24*time.Hour))
+	case duration >= time.Hour:
+		return fmt.Sprintf("%dh", duration/time.Hour)
+	case duration >= time.Minute:
+		return fmt.Sprintf("%dm", duration/time.Minute)
+	case duration >= time.Second:
+		return fmt.Sprintf("%ds", duration/time.Second)
+	default:
+		return fmt.Sprintf("%dms", duration/time.Millisecond)
+	}
+}
+
+// Format formats the entry as a JSON object on a single line
+func (formatter *JSONFormatter) Format(entry *Entry) []byte {
+	var buffer bytes.Buffer
+
+	buffer.WriteByte('{')
+	writeJSONPair(&buffer, "time", entry.Time.Format(time.RFC3339Nano))
+	buffer.WriteByte(',')
+	writeJSONPair(&buffer, "level", entry.Level.String())
+	buffer.WriteByte(',')
+	writeJSONPair(&buffer, "prefix", entry.Prefix)
+	buffer.WriteByte(',')
+	writeJSONPair(&buffer, "msg", entry.Message)
+
+	if entry.Caller != nil {
+		buffer.WriteByte(',')
+		writeJSONPair(&buffer, "caller", entry.Caller.String())
+	}
+
+	if causes := errorCauses(entry.Errors); len(causes) > 0 {
+		buffer.WriteByte(',')
+		writeJSONPair(&buffer, "causes", causeTexts(causes))
+	}
+
+	if len(entry.Stack) > 0 {
+		buffer.WriteByte(',')
+		writeJSONPair(&buffer, "stack", stackLines(entry.Stack))
+	}
+
+	writeJSONFields(&buffer, entry.Fields)
+
+	buffer.WriteString("}\n")
+	return buffer.Bytes()
+}
+
+func writeJSONFields(buffer *bytes.Buffer, fields []Field) {
+	for _, field := range fields {
+		buffer.WriteByte(',')
+		writeJSONPair(buffer, field.Key, field.Value)
+	}
+}
+
+func writeJSONPair(buffer *bytes.Buffer, key string, value interface{}) {
+	encodedKey, _ := json.Marshal(key)
+	buffer.Write(encodedKey)
+	buffer.WriteByte(':')
+
+	switch typedValue := value.(type) {
+	case []Field:
+		buffer.WriteByte('{')
+		if len(typedValue) > 0 {
+			var groupBuffer bytes.Buffer
+			writeJSONFields(&groupBuffer, typedValue)
+			buffer.Write(groupBuffer.Bytes()[1:])
+		}
+		buffer.WriteByte('}')
+		return
+	case error:
+		value = errorText(typedValue)
+	}
+
+	encodedValue, marshalError := json.Marshal(value)
+	if marshalError != nil {
+		encodedValue, _ = json.Marshal(fmt.Sprint(value))
+	}
+
+	buffer.Write(encodedValue)
+}
+
+// Format formats the entry as a logfmt line
+func (formatter *LogfmtFormatter) Format(entry *Entry) []byte {
+	var buffer bytes.Buffer
+
+	writeLogfmtPair(&buffer, "time", entry.Time.Format(time.RFC3339Nano))
+	buffer.WriteByte(' ')
+	writeLogfmtPair(&buffer, "level", entry.Level.String())
+	buffer.WriteByte(' ')
+	writeLogfmtPair(&buffer, "prefix", entry.Prefix)
+	buffer.WriteByte(' ')
+	writeLogfmtPair(&buffer, "msg", entry.Message)
+
+	if entry.Caller != nil {
+		buffer.WriteByte(' ')
+		writeLogfmtPair(&buffer, "caller", entry.Caller.String())
+	}
+
+	if causes := errorCauses(entry.Errors); len(causes) > 0 {
+		buffer.WriteByte(' ')
+		writeLogfmtPair(&buffer, "causes", strings.Join(causeTexts(causes), "; "))
+	}
+
+	if len(entry.Stack) > 0 {
+		buffer.WriteByte(' ')
+		writeLogfmtPair(&buffer, "stack", strings.Join(stackLines(entry.Stack), "\n"))
+	}
+
+	for _, field := range flattenFields("", entry.Fields) {
+		buffer.WriteByte(' ')
+		writeLogfmtPair(&buffer, field.Key, stringValue(field.Value))
+	}
+
+	buffer.WriteByte('\n')
+	return buffer.Bytes()
+}
+
+func writeLogfmtPair(buffer *bytes.Buffer, key string, value string) {
+	buffer.WriteString(key)
+	buffer.WriteByte('=')
+
+	if value == "" || strings.ContainsAny(value, " =\"\\") || strings.IndexFunc(value, isControl) != -1 {
+		buffer.WriteString(strconv.Quote(value))
+		return
+	}
+
+	buffer.WriteString(value)
+}
+
+func isControl(character rune) bool {
+	return character < ' ' || character == 0x7f
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// JournaldSocket is the socket of the native journald protocol
+const JournaldSocket = "/run/systemd/journal/socket"
+
+// reservedJournalFields are the journal fields written by the sink itself or
+// interpreted by journald, fields with these names get a "FIELD_" prefix
+var reservedJournalFields = map[string]bool{
+	"CODE_FILE":          true,
+	"CODE_FUNC":          true,
+	"CODE_LINE":          true,
+	"ERRNO":              true,
+	"MESSAGE":            true,
+	"MESSAGE_ID":         true,
+	"PRIORITY":           true,
+	"SIMPLELOGGER_LEVEL": true,
+	"SYSLOG_FACILITY":    true,
+	"SYSLOG_IDENTIFIER":  true,
+	"SYSLOG_PID":         true,
+	"SYSLOG_TIMESTAMP":   true,
+}
+
+// JournaldSink is a sink which sends the entries with the native protocol to
+// journald. The prefix of an entry is sent as SYSLOG_IDENTIFIER, its fields
+// as upper case journal fields (e.g. "repo" as REPO, "message" as
+// FIELD_MESSAGE, "2fa" as FIELD_2FA).
+type JournaldSink struct {
+	Address string
+
+	conn  *net.UnixConn
+	mutex sync.Mutex
+}
+
+// NewJournaldSink returns a new instance of JournaldSink which sends the
+// entries to the socket at address (JournaldSocket if empty)
+func NewJournaldSink(address string) (*JournaldSink, error) {
+	if address == "" {
+		address = JournaldSocket
+	}
+
+	sink := &JournaldSink{Address: address}
+
+	if connectError := sink.connect(); connectError != nil {
+		return nil, connectError
+	}
+
+	return sink, nil
+}
+
+func (sink *JournaldSink) connect() error {
+	conn, dialError := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: sink.Address, Net: "unixgram"})
+	if dialError != nil {
+		return dialError
+	}
+
+	sink.conn = conn
+	return nil
+}
+
+// WriteEntry sends the entry to journald
+func (sink *JournaldSink) WriteEntry(entry *Entry) error {
+	var buffer bytes.Buffer
+
+	writeJournalField(&buffer, "MESSAGE", entry.Message)
+	writeJournalField(&buffer, "PRIORITY", fmt.Sprint(entry.Level.SyslogSeverity()))
+	writeJournalField(&buffer, "SYSLOG_IDENTIFIER", entry.Prefix)
+	writeJournalField(&buffer, "SIMPLELOGGER_LEVEL", entry.Level.String())
+
+	for _, field := range flattenFields("", entry.Fields) {
+		if name := journalFieldName(field.Key); name != "" {
+			writeJournalField(&buffer, name, stringValue(field.Value))
+		}
+	}
+
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if sink.conn == nil {
+		if connectError := sink.connect(); connectError != nil {
+			return connectError
+		}
+	}
+
+	_, writeError := sink.conn.Write(buffer.Bytes())
+	return writeError
+}
+
+// Close closes the connection to journald
+func (sink *JournaldSink) Close() error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if sink.conn == nil {
+		return nil
+	}
+
+	closeError := sink.conn.Close()
+	sink.conn = nil
+
+	return closeError
+}
+
+// writeJournalField writes a field in the native journal format. Values with
+// newlines are written with their length as 64 bit little endian integer.
+func writeJournalField(buffer *bytes.Buffer, name string, value string) {
+	buffer.WriteString(name)
+
+	if !strings.Contains(value, "\n") {
+		buffer.WriteByte('=')
+		buffer.WriteString(value)
+		buffer.WriteByte('\n')
+		return
+	}
+
+	buffer.WriteByte('\n')
+	binary.Write(buffer, binary.LittleEndian, uint64(len(value)))
+	buffer.WriteString(value)
+	buffer.WriteByte('\n')
+}
+
+// journalFieldName converts the key into a valid journal field name, which
+// consists of upper case letters, digits and underscores. Names which start
+// with an underscore or a digit or are reserved get a "FIELD_" prefix.
+func journalFieldName(key string) string {
+	name := strings.Map(func(character rune) rune {
+		switch {
+		case character >= 'a' && character <= 'z':
+			return character - 'a' + 'A'
+		case character >= 'A' && character <= 'Z', character >= '0' && character <= '9':
+			return character
+		default:
+			return '_'
+		}
+	}, key)
+
+	if reservedJournalFields[name] || strings.IndexAny(name, "_0123456789") == 0 {
+		name = "FIELD_" + name
+	}
+
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	return name
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+)
+
+// parseJournalFields decodes a datagram of the native journal protocol
+func parseJournalFields(t *testing.T, datagram []byte) map[string][]string {
+	t.Helper()
+
+	fields := map[string][]string{}
+
+	for len(datagram) > 0 {
+		lineEnd := bytes.IndexByte(datagram, '\n')
+		if lineEnd == -1 {
+			t.Fatalf("unterminated journal field %q", datagram)
+		}
+
+		line := string(datagram[:lineEnd])
+		datagram = datagram[lineEnd+1:]
+
+		if name, value, found := strings.Cut(line, "="); found {
+			fields[name] = append(fields[name], value)
+			continue
+		}
+
+		length := binary.LittleEndian.Uint64(datagram[:8])
+		value := string(datagram[8 : 8+length])
+		if datagram[8+length] != '\n' {
+			t.Fatalf("binary journal field %q is not terminated by a newline", line)
+		}
+
+		fields[line] = append(fields[line], value)
+		datagram = datagram[9+length:]
+	}
+
+	return fields
+}
+
+func TestJournaldSink(t *testing.T) {
+	conn, address := listenUnixgram(t)
+
+	sink, sinkError := simplelogger.NewJournaldSink(address)
+	if sinkError != nil {
+		t.Fatal(sinkError)
+	}
+	defer sink.Close()
+
+	logger := newSinkLogger(sink)
+	logger.Error("line one\nline two")
+	logger.Info("reserved fields", "message", "user", "priority", "high", "repo-name", "go-tools", "2fa", "on", "fa", "off", "_private", "yes")
+
+	errorFields := parseJournalFields(t, readDatagram(t, conn))
+
+	expectedErrorFields := map[string]string{
+		"MESSAGE":            "line one\nline two",
+		"PRIORITY":           "3",
+		"SIMPLELOGGER_LEVEL": "error",
+		"SYSLOG_IDENTIFIER":  "my-app",
+	}
+
+	for name, value := range expectedErrorFields {
+		if values := errorFields[name]; len(values) != 1 || values[0] != value {
+			t.Errorf("field %s = %q, want [%q]", name, values, value)
+		}
+	}
+
+	infoFields := parseJournalFields(t, readDatagram(t, conn))
+
+	expectedInfoFields := map[string]string{
+		"FA":             "off",
+		"FIELD_2FA":      "on",
+		"FIELD_MESSAGE":  "user",
+		"FIELD_PRIORITY": "high",
+		"FIELD__PRIVATE": "yes",
+		"MESSAGE":        "reserved fields",
+		"PRIORITY":       "6",
+		"REPO_NAME":      "go-tools",
+	}
+
+	for name, value := range expectedInfoFields {
+		if values := infoFields[name]; len(values) != 1 || values[0] != value {
+			t.Errorf("field %s = %q, want [%q]", name, values, value)
+		}
+	}
+}
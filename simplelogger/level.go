@@ -0,0 +1,69 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is the severity of a log message
+type Level int
+
+// The available log levels, from the most to the least verbose
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelTrace: "trace",
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// String returns the lower case name of the level
+func (level Level) String() string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("level(%d)", int(level))
+}
+
+// ParseLevel takes a level name (e.g. "debug") and returns the according level
+func ParseLevel(name string) (Level, error) {
+	normalizedName := strings.ToLower(strings.TrimSpace(name))
+
+	if normalizedName == "warning" {
+		return LevelWarn, nil
+	}
+
+	for level, levelName := range levelNames {
+		if levelName == normalizedName {
+			return level, nil
+		}
+	}
+
+	return LevelTrace, fmt.Errorf("Unknown log level \"%s\"", name)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package loggertest provides helpers to assert on the output of a SimpleLogger
+// in tests.
+package loggertest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+)
+
+// Recorder is a hook which stores all entries of a logger in memory. It records
+// also if the logger is disabled.
+type Recorder struct {
+	entries []simplelogger.Entry
+	mutex   sync.Mutex
+}
+
+// TestingHook is a hook which forwards all entries to testing.TB.Log
+type TestingHook struct {
+	t testing.TB
+}
+
+// NewRecorder returns a new instance of Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Attach returns a new Recorder which records the entries of the logger and its children
+func Attach(logger *simplelogger.SimpleLogger) *Recorder {
+	recorder := NewRecorder()
+	logger.AddHook(recorder)
+	return recorder
+}
+
+// Record returns a new Recorder which records the entries of the logger and its
+// children until the test has finished
+func Record(t testing.TB, logger *simplelogger.SimpleLogger) *Recorder {
+	recorder := Attach(logger)
+	t.Cleanup(func() {
+		logger.RemoveHook(recorder)
+	})
+	return recorder
+}
+
+// Fire stores the entry
+func (recorder *Recorder) Fire(entry *simplelogger.Entry) {
+	entryCopy := *entry
+	entryCopy.Fields = append([]simplelogger.Field{}, entry.Fields...)
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.entries = append(recorder.entries, entryCopy)
+}
+
+// All returns all recorded entries
+func (recorder *Recorder) All() []simplelogger.Entry {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return append([]simplelogger.Entry{}, recorder.entries...)
+}
+
+// Entries returns all recorded entries with the level
+func (recorder *Recorder) Entries(level simplelogger.Level) []simplelogger.Entry {
+	var entries []simplelogger.Entry
+
+	for _, entry := range recorder.All() {
+		if entry.Level == level {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// Contains returns whether a recorded message contains the text
+func (recorder *Recorder) Contains(text string) bool {
+	for _, entry := range recorder.All() {
+		if strings.Contains(entry.Message, text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Field returns the value of the field with the key in the last recorded entry
+// which has it
+func (recorder *Recorder) Field(key string) (interface{}, bool) {
+	entries := recorder.All()
+
+	for index := len(entries) - 1; index >= 0; index-- {
+		for _, field := range entries[index].Fields {
+			if field.Key == key {
+				return field.Value, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// Reset removes all recorded entries
+func (recorder *Recorder) Reset() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.entries = nil
+}
+
+// NewTestingHook returns a new instance of TestingHook
+func NewTestingHook(t testing.TB) *TestingHook {
+	return &TestingHook{t: t}
+}
+
+// Log forwards the entries of the logger and its children to t.Log until the
+// test has finished
+func Log(t testing.TB, logger *simplelogger.SimpleLogger) {
+	hook := NewTestingHook(t)
+	logger.AddHook(hook)
+	t.Cleanup(func() {
+		logger.RemoveHook(hook)
+	})
+}
+
+// Fire logs the entry with t.Log
+func (hook *TestingHook) Fire(entry *simplelogger.Entry) {
+	hook.t.Helper()
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "[%s] %s %s", entry.Level, entry.Prefix, entry.Message)
+
+	for _, field := range entry.Fields {
+		fmt.Fprintf(&builder, " %s=%v", field.Key, field.Value)
+	}
+
+	hook.t.Log(builder.String())
+}
@@ -0,0 +1,156 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package loggertest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+	"github.com/ffflorian/go-tools/simplelogger/loggertest"
+)
+
+// discardSink drops all entries, so that the tests don't write to stderr
+type discardSink struct{}
+
+func (discardSink) WriteEntry(*simplelogger.Entry) error {
+	return nil
+}
+
+func newTestLogger(t *testing.T, enabled bool) *simplelogger.SimpleLogger {
+	t.Helper()
+
+	logger := simplelogger.New("loggertest", enabled, false)
+	logger.Sinks = []simplelogger.Sink{discardSink{}}
+	t.Cleanup(func() { simplelogger.Unregister(logger) })
+
+	return logger
+}
+
+func TestRecorderDisabledLogger(t *testing.T) {
+	logger := newTestLogger(t, false)
+	logger.SetLevel(simplelogger.LevelError)
+	recorder := loggertest.Record(t, logger)
+
+	logger.Debug("below the level and disabled")
+	logger.Extend("child").Info("from a child")
+
+	entries := recorder.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[1].Prefix != "loggertest/child" {
+		t.Errorf("unexpected prefix %q", entries[1].Prefix)
+	}
+}
+
+func TestRecorderQueries(t *testing.T) {
+	logger := newTestLogger(t, true)
+	recorder := loggertest.Record(t, logger)
+
+	logger.Debug("searching", "dir", "/a")
+	logger.Info("found repository", "url", "https://github.com/user/repo")
+	logger.Debug("searching", "dir", "/b")
+	logger.Error("request failed")
+
+	if debugEntries := recorder.Entries(simplelogger.LevelDebug); len(debugEntries) != 2 || debugEntries[1].Message != "searching" {
+		t.Errorf("unexpected debug entries %v", debugEntries)
+	}
+	if warnEntries := recorder.Entries(simplelogger.LevelWarn); len(warnEntries) != 0 {
+		t.Errorf("unexpected warn entries %v", warnEntries)
+	}
+
+	if !recorder.Contains("found repo") || !recorder.Contains("failed") || recorder.Contains("missing") {
+		t.Error("Contains doesn't match the substrings of the messages")
+	}
+
+	if value, found := recorder.Field("dir"); !found || value != "/b" {
+		t.Errorf("Field(\"dir\") = %v, %t, want the value of the last entry", value, found)
+	}
+	if value, found := recorder.Field("url"); !found || value != "https://github.com/user/repo" {
+		t.Errorf("Field(\"url\") = %v, %t", value, found)
+	}
+	if _, found := recorder.Field("missing"); found {
+		t.Error("Field found a missing key")
+	}
+
+	recorder.Reset()
+	if entries := recorder.All(); len(entries) != 0 {
+		t.Errorf("Reset kept %d entries", len(entries))
+	}
+}
+
+func TestRecorderCopiesFields(t *testing.T) {
+	recorder := loggertest.NewRecorder()
+	entry := &simplelogger.Entry{Fields: []simplelogger.Field{{Key: "key", Value: "before"}}, Message: "message"}
+
+	recorder.Fire(entry)
+	entry.Fields[0].Value = "after"
+	entry.Message = "changed"
+
+	recorded := recorder.All()[0]
+	if recorded.Message != "message" || recorded.Fields[0].Value != "before" {
+		t.Errorf("the recorded entry was changed: %+v", recorded)
+	}
+}
+
+func TestRecordRemovesHook(t *testing.T) {
+	logger := newTestLogger(t, true)
+	var recorder *loggertest.Recorder
+
+	t.Run("record", func(t *testing.T) {
+		recorder = loggertest.Record(t, logger)
+		logger.Info("during the test")
+	})
+
+	logger.Info("after the test")
+
+	if entries := recorder.All(); len(entries) != 1 || entries[0].Message != "during the test" {
+		t.Errorf("the hook was not removed after the test: %v", entries)
+	}
+}
+
+// logRecorder is a testing.TB which stores the arguments of Log
+type logRecorder struct {
+	testing.TB
+
+	lines []string
+}
+
+func (recorder *logRecorder) Helper() {}
+
+func (recorder *logRecorder) Log(args ...interface{}) {
+	recorder.lines = append(recorder.lines, fmt.Sprint(args...))
+}
+
+func TestLog(t *testing.T) {
+	logger := newTestLogger(t, false)
+	testLog := &logRecorder{TB: t}
+
+	t.Run("log", func(t *testing.T) {
+		testLog.TB = t
+		loggertest.Log(testLog, logger)
+		logger.Warn("disk almost full", "disk", "/dev/sda")
+	})
+
+	logger.Warn("after the test")
+
+	if len(testLog.lines) != 1 || testLog.lines[0] != "[warn] loggertest disk almost full disk=/dev/sda" {
+		t.Errorf("unexpected log lines %q", testLog.lines)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matcher decides which namespaces are enabled, based on a debug.js style
+// specification like "gh-open/*,-gh-open/githubclient". Patterns are separated
+// by commas or spaces, "*" is a wildcard and a leading "-" excludes the
+// namespaces matching the pattern. A pattern can be followed by "=<level>" to
+// set the minimum level for its namespaces.
+type Matcher struct {
+	names []pattern
+	skips []pattern
+}
+
+type pattern struct {
+	level       Level
+	regExp      *regexp.Regexp
+	specificity int
+	text        string
+}
+
+var separatorRegExp = regexp.MustCompile(`[\s,]+`)
+
+// NewMatcher parses the specification and returns a new instance of Matcher.
+// Patterns with an unknown level are left out, see ParseMatcher.
+func NewMatcher(spec string) *Matcher {
+	matcher, _ := ParseMatcher(spec)
+	return matcher
+}
+
+// ParseMatcher parses the specification and returns a new instance of Matcher
+// and an error for every pattern with an unknown level (e.g. "gh-open=wran").
+// These patterns are left out, so their namespaces are not enabled.
+func ParseMatcher(spec string) (*Matcher, error) {
+	var parseErrors []error
+
+	matcher := &Matcher{}
+
+	for _, part := range separatorRegExp.Split(spec, -1) {
+		if part == "" {
+			continue
+		}
+
+		level := LevelTrace
+
+		if index := strings.LastIndex(part, "="); index != -1 {
+			parsedLevel, parseError := ParseLevel(part[index+1:])
+			if parseError != nil {
+				parseErrors = append(parseErrors, fmt.Errorf("Invalid pattern \"%s\": %w", part, parseError))
+				continue
+			}
+			level = parsedLevel
+			part = part[:index]
+		}
+
+		isSkip := strings.HasPrefix(part, "-")
+		part = strings.TrimPrefix(part, "-")
+
+		if part == "" {
+			continue
+		}
+
+		newPattern := pattern{
+			level:       level,
+			regExp:      compilePattern(part),
+			specificity: len(part) - strings.Count(part, "*"),
+			text:        part,
+		}
+
+		if isSkip {
+			matcher.skips = append(matcher.skips, newPattern)
+		} else {
+			matcher.names = append(matcher.names, newPattern)
+		}
+	}
+
+	return matcher, errors.Join(parseErrors...)
+}
+
+func compilePattern(text string) *regexp.Regexp {
+	quoted := strings.ReplaceAll(regexp.QuoteMeta(text), `\*`, ".*?")
+	return regexp.MustCompile("^" + quoted + "$")
+}
+
+// Enabled returns whether the namespace is enabled by the specification
+func (matcher *Matcher) Enabled(namespace string) bool {
+	_, enabled := matcher.Level(namespace)
+	return enabled
+}
+
+// Excludes returns whether the namespace is explicitly excluded by a "-" pattern
+func (matcher *Matcher) Excludes(namespace string) bool {
+	for _, skip := range matcher.skips {
+		if skip.regExp.MatchString(namespace) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Level returns the minimum level for the namespace and whether the namespace
+// is enabled at all. If several patterns match, the most specific one (the one
+// with the most non-wildcard characters) wins.
+func (matcher *Matcher) Level(namespace string) (Level, bool) {
+	if matcher.Excludes(namespace) {
+		return LevelTrace, false
+	}
+
+	var bestMatch *pattern
+
+	for index := range matcher.names {
+		name := &matcher.names[index]
+		if !name.regExp.MatchString(namespace) {
+			continue
+		}
+		if bestMatch == nil || name.specificity >= bestMatch.specificity {
+			bestMatch = name
+		}
+	}
+
+	if bestMatch == nil {
+		return LevelTrace, false
+	}
+
+	return bestMatch.level, true
+}
+
+// String returns the specification of the matcher in normalized form
+func (matcher *Matcher) String() string {
+	var parts []string
+
+	for _, name := range matcher.names {
+		parts = append(parts, name.String())
+	}
+
+	for _, skip := range matcher.skips {
+		parts = append(parts, "-"+skip.String())
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (pattern *pattern) String() string {
+	if pattern.level == LevelTrace {
+		return pattern.text
+	}
+
+	return pattern.text + "=" + pattern.level.String()
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+)
+
+func TestMatcherEnabled(t *testing.T) {
+	testCases := []struct {
+		disabled []string
+		enabled  []string
+		spec     string
+	}{
+		{spec: "", disabled: []string{"gh-open", "*"}},
+		{spec: "gh-open", enabled: []string{"gh-open"}, disabled: []string{"gh-open/gitclient", "gh-openx", "gh"}},
+		{spec: "gh-open*", enabled: []string{"gh-open", "gh-open/gitclient", "gh-openx"}, disabled: []string{"my-timezone"}},
+		{spec: "gh-open/*", enabled: []string{"gh-open/gitclient", "gh-open/"}, disabled: []string{"gh-open"}},
+		{spec: "*/gitclient", enabled: []string{"gh-open/gitclient"}, disabled: []string{"gh-open/gitclient/x"}},
+		{spec: "*", enabled: []string{"gh-open", "my-timezone/nominatim"}},
+		{spec: "gh.open", enabled: []string{"gh.open"}, disabled: []string{"gh-open"}},
+		{spec: " gh-open, my-timezone  other,,", enabled: []string{"gh-open", "my-timezone", "other"}, disabled: []string{""}},
+	}
+
+	for _, testCase := range testCases {
+		matcher, parseError := simplelogger.ParseMatcher(testCase.spec)
+		if parseError != nil {
+			t.Errorf("%q: %s", testCase.spec, parseError)
+			continue
+		}
+
+		for _, namespace := range testCase.enabled {
+			if !matcher.Enabled(namespace) {
+				t.Errorf("%q doesn't enable %q", testCase.spec, namespace)
+			}
+		}
+		for _, namespace := range testCase.disabled {
+			if matcher.Enabled(namespace) {
+				t.Errorf("%q enables %q", testCase.spec, namespace)
+			}
+		}
+	}
+}
+
+func TestMatcherNegation(t *testing.T) {
+	for _, spec := range []string{"*,-gh-open/githubclient", "-gh-open/githubclient,*", "gh-open/githubclient=debug,-gh-open/*,*"} {
+		matcher := simplelogger.NewMatcher(spec)
+
+		if matcher.Enabled("gh-open/githubclient") || !matcher.Excludes("gh-open/githubclient") {
+			t.Errorf("%q doesn't exclude gh-open/githubclient", spec)
+		}
+		if !matcher.Enabled("gh-open") || matcher.Excludes("gh-open") {
+			t.Errorf("%q doesn't enable gh-open", spec)
+		}
+		if level, enabled := matcher.Level("gh-open/githubclient"); enabled || level != simplelogger.LevelTrace {
+			t.Errorf("%q: Level of an excluded namespace = %s, %t", spec, level, enabled)
+		}
+	}
+
+	matcher := simplelogger.NewMatcher("-gh-open")
+	if matcher.Enabled("gh-open") || !matcher.Excludes("gh-open") || matcher.Excludes("my-timezone") || matcher.Enabled("my-timezone") {
+		t.Error("a spec with only a negation enables or excludes the wrong namespaces")
+	}
+}
+
+func TestMatcherLevel(t *testing.T) {
+	matcher := simplelogger.NewMatcher("*=error,gh-open*=warn,gh-open/*=info,gh-open/gitclient=DEBUG,my-timezone=warning,a*=warn,a*=trace")
+
+	testCases := []struct {
+		level     simplelogger.Level
+		namespace string
+	}{
+		{level: simplelogger.LevelError, namespace: "other"},
+		{level: simplelogger.LevelWarn, namespace: "gh-open"},
+		{level: simplelogger.LevelInfo, namespace: "gh-open/githubclient"},
+		{level: simplelogger.LevelDebug, namespace: "gh-open/gitclient"},
+		{level: simplelogger.LevelWarn, namespace: "my-timezone"},
+		// with the same specificity, the last pattern wins
+		{level: simplelogger.LevelTrace, namespace: "abc"},
+	}
+
+	for _, testCase := range testCases {
+		level, enabled := matcher.Level(testCase.namespace)
+		if !enabled || level != testCase.level {
+			t.Errorf("Level(%q) = %s, %t, want %s", testCase.namespace, level, enabled, testCase.level)
+		}
+	}
+
+	if level, enabled := simplelogger.NewMatcher("gh-open").Level("gh-open"); !enabled || level != simplelogger.LevelTrace {
+		t.Errorf("a pattern without level has level %s", level)
+	}
+}
+
+func TestParseMatcherUnknownLevel(t *testing.T) {
+	matcher, parseError := simplelogger.ParseMatcher("gh-open=wran,my-timezone,other=loud,-skip=nope")
+
+	if parseError == nil {
+		t.Fatal("ParseMatcher returned no error for unknown levels")
+	}
+
+	for _, part := range []string{`Invalid pattern "gh-open=wran"`, `Invalid pattern "other=loud"`, `Invalid pattern "-skip=nope"`, `Unknown log level "wran"`} {
+		if !strings.Contains(parseError.Error(), part) {
+			t.Errorf("error %q doesn't mention %s", parseError, part)
+		}
+	}
+
+	if matcher.Enabled("gh-open") || matcher.Enabled("other") || matcher.Excludes("skip") {
+		t.Error("a pattern with an unknown level was used")
+	}
+	if !matcher.Enabled("my-timezone") {
+		t.Error("the valid pattern was left out")
+	}
+
+	if matcher := simplelogger.NewMatcher("gh-open=wran,my-timezone"); matcher.Enabled("gh-open") || !matcher.Enabled("my-timezone") {
+		t.Error("NewMatcher doesn't skip patterns with an unknown level")
+	}
+}
+
+func TestMatcherString(t *testing.T) {
+	matcher := simplelogger.NewMatcher(" -gh-open/githubclient gh-open*=Info,my-timezone=trace ")
+
+	if spec := matcher.String(); spec != "gh-open*=info,my-timezone,-gh-open/githubclient" {
+		t.Errorf("unexpected normalized specification %q", spec)
+	}
+}
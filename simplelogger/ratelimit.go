@@ -0,0 +1,235 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Deduplicator is a filter which drops a message if it is identical to the last
+// message of its prefix (same level, message and fields) and repeats within Window. When the window has passed
+// or another message is logged, it writes a summary like "last message
+// repeated 532 times".
+type Deduplicator struct {
+	Window time.Duration
+
+	last  map[string]*repetition
+	mutex sync.Mutex
+}
+
+type repetition struct {
+	count     int
+	emit      func(entry *Entry)
+	entry     Entry
+	firstSeen time.Time
+	timer     *time.Timer
+}
+
+// RateLimiter is a filter with a token bucket per prefix. Every prefix may write
+// Burst entries at once and Rate entries per second on average, unless a
+// different limit is set for its namespace with SetLimit. The number of dropped
+// entries is written before the next entry which passes.
+type RateLimiter struct {
+	Burst int
+	Rate  float64
+
+	buckets map[string]*tokenBucket
+	limits  map[string]rateLimit
+	mutex   sync.Mutex
+}
+
+type rateLimit struct {
+	burst int
+	rate  float64
+}
+
+type tokenBucket struct {
+	dropped    int
+	lastRefill time.Time
+	tokens     float64
+}
+
+// NewDeduplicator returns a new instance of Deduplicator
+func NewDeduplicator(window time.Duration) *Deduplicator {
+	return &Deduplicator{
+		Window: window,
+		last:   map[string]*repetition{},
+	}
+}
+
+// Filter returns false if the entry repeats the last entry of its prefix
+func (deduplicator *Deduplicator) Filter(entry *Entry, emit func(entry *Entry)) bool {
+	deduplicator.mutex.Lock()
+	defer deduplicator.mutex.Unlock()
+
+	if deduplicator.last == nil {
+		deduplicator.last = map[string]*repetition{}
+	}
+
+	last, found := deduplicator.last[entry.Prefix]
+	if found && sameEntry(&last.entry, entry) && entry.Time.Sub(last.firstSeen) < deduplicator.Window {
+		last.count++
+		last.emit = emit
+		return false
+	}
+
+	if found {
+		deduplicator.flush(entry.Prefix, last)
+	}
+
+	newRepetition := &repetition{
+		emit:      emit,
+		entry:     *entry,
+		firstSeen: entry.Time,
+	}
+	newRepetition.timer = time.AfterFunc(deduplicator.Window, func() {
+		deduplicator.mutex.Lock()
+		defer deduplicator.mutex.Unlock()
+
+		if deduplicator.last[entry.Prefix] == newRepetition {
+			deduplicator.flush(entry.Prefix, newRepetition)
+		}
+	})
+
+	deduplicator.last[entry.Prefix] = newRepetition
+	return true
+}
+
+// sameEntry returns true if both entries have the same level, message and
+// fields, comparing the fields as they are displayed
+func sameEntry(entry *Entry, otherEntry *Entry) bool {
+	if entry.Level != otherEntry.Level || entry.Message != otherEntry.Message {
+		return false
+	}
+
+	fields := flattenFields("", entry.Fields)
+	otherFields := flattenFields("", otherEntry.Fields)
+	if len(fields) != len(otherFields) {
+		return false
+	}
+
+	for index, field := range fields {
+		if field.Key != otherFields[index].Key || stringValue(field.Value) != stringValue(otherFields[index].Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// flush writes the summary of the repetition, if there was one, and forgets it
+func (deduplicator *Deduplicator) flush(prefix string, last *repetition) {
+	last.timer.Stop()
+	delete(deduplicator.last, prefix)
+
+	if last.count == 0 {
+		return
+	}
+
+	last.emit(&Entry{
+		Fields:  []Field{{Key: "repeated", Value: last.count}},
+		Level:   last.entry.Level,
+		Message: fmt.Sprintf("last message repeated %d times", last.count),
+		Prefix:  prefix,
+		Time:    time.Now(),
+	})
+}
+
+// NewRateLimiter returns a new instance of RateLimiter
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		Burst:   burst,
+		Rate:    rate,
+		buckets: map[string]*tokenBucket{},
+		limits:  map[string]rateLimit{},
+	}
+}
+
+// SetLimit sets a different rate and burst for all prefixes in the namespace
+// (e.g. "gh-open/githubclient"). The limit of the longest matching namespace is used.
+func (rateLimiter *RateLimiter) SetLimit(namespace string, rate float64, burst int) {
+	rateLimiter.mutex.Lock()
+	defer rateLimiter.mutex.Unlock()
+
+	if rateLimiter.limits == nil {
+		rateLimiter.limits = map[string]rateLimit{}
+	}
+
+	rateLimiter.limits[namespace] = rateLimit{burst: burst, rate: rate}
+}
+
+func (rateLimiter *RateLimiter) limitFor(prefix string) rateLimit {
+	limit := rateLimit{burst: rateLimiter.Burst, rate: rateLimiter.Rate}
+	longestMatch := -1
+
+	for namespace, namespaceLimit := range rateLimiter.limits {
+		if inNamespace(prefix, namespace) && len(namespace) > longestMatch {
+			limit = namespaceLimit
+			longestMatch = len(namespace)
+		}
+	}
+
+	return limit
+}
+
+// Filter returns false if the bucket of the entry's prefix has no tokens left
+func (rateLimiter *RateLimiter) Filter(entry *Entry, emit func(entry *Entry)) bool {
+	rateLimiter.mutex.Lock()
+	defer rateLimiter.mutex.Unlock()
+
+	if rateLimiter.buckets == nil {
+		rateLimiter.buckets = map[string]*tokenBucket{}
+	}
+
+	limit := rateLimiter.limitFor(entry.Prefix)
+	now := time.Now()
+
+	bucket, found := rateLimiter.buckets[entry.Prefix]
+	if !found {
+		bucket = &tokenBucket{lastRefill: now, tokens: float64(limit.burst)}
+		rateLimiter.buckets[entry.Prefix] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.lastRefill).Seconds() * limit.rate
+	if bucket.tokens > float64(limit.burst) {
+		bucket.tokens = float64(limit.burst)
+	}
+	bucket.lastRefill = now
+
+	if bucket.tokens < 1 {
+		bucket.dropped++
+		return false
+	}
+
+	bucket.tokens--
+
+	if bucket.dropped > 0 {
+		emit(&Entry{
+			Fields:  []Field{{Key: "dropped", Value: bucket.dropped}},
+			Level:   LevelWarn,
+			Message: fmt.Sprintf("rate limit exceeded, dropped %d messages", bucket.dropped),
+			Prefix:  entry.Prefix,
+			Time:    now,
+		})
+		bucket.dropped = 0
+	}
+
+	return true
+}
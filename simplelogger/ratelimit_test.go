@@ -0,0 +1,310 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+)
+
+// entrySink is a sink which stores the written entries in memory
+type entrySink struct {
+	entries []simplelogger.Entry
+	mutex   sync.Mutex
+}
+
+func (sink *entrySink) WriteEntry(entry *simplelogger.Entry) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	sink.entries = append(sink.entries, *entry)
+	return nil
+}
+
+func (sink *entrySink) messages() []string {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	var messages []string
+	for _, entry := range sink.entries {
+		messages = append(messages, entry.Message)
+	}
+
+	return messages
+}
+
+// emitted collects the entries a filter emits
+type emitted struct {
+	entries chan *simplelogger.Entry
+}
+
+func newEmitted() *emitted {
+	return &emitted{entries: make(chan *simplelogger.Entry, 16)}
+}
+
+func (emitted *emitted) emit(entry *simplelogger.Entry) {
+	emitted.entries <- entry
+}
+
+func (emitted *emitted) next(t *testing.T) *simplelogger.Entry {
+	t.Helper()
+
+	select {
+	case entry := <-emitted.entries:
+		return entry
+	case <-time.After(5 * time.Second):
+		t.Fatal("no entry was emitted")
+		return nil
+	}
+}
+
+func (emitted *emitted) none(t *testing.T) {
+	t.Helper()
+
+	select {
+	case entry := <-emitted.entries:
+		t.Fatalf("unexpected entry %q", entry.Message)
+	default:
+	}
+}
+
+func newEntry(message string, keyvals ...interface{}) *simplelogger.Entry {
+	entry := &simplelogger.Entry{
+		Level:   simplelogger.LevelInfo,
+		Message: message,
+		Prefix:  "my-app",
+		Time:    time.Now(),
+	}
+
+	for index := 0; index+1 < len(keyvals); index += 2 {
+		entry.Fields = append(entry.Fields, simplelogger.Field{Key: keyvals[index].(string), Value: keyvals[index+1]})
+	}
+
+	return entry
+}
+
+func TestDeduplicatorSummary(t *testing.T) {
+	deduplicator := simplelogger.NewDeduplicator(time.Hour)
+	emitted := newEmitted()
+
+	if !deduplicator.Filter(newEntry("request failed"), emitted.emit) {
+		t.Fatal("the first entry was dropped")
+	}
+
+	for index := 0; index < 3; index++ {
+		if deduplicator.Filter(newEntry("request failed"), emitted.emit) {
+			t.Fatalf("repetition %d was not dropped", index+1)
+		}
+	}
+	emitted.none(t)
+
+	if !deduplicator.Filter(newEntry("request succeeded"), emitted.emit) {
+		t.Fatal("a different entry was dropped")
+	}
+
+	summary := emitted.next(t)
+	if summary.Message != "last message repeated 3 times" {
+		t.Errorf("unexpected summary %q", summary.Message)
+	}
+	if len(summary.Fields) != 1 || summary.Fields[0].Key != "repeated" || summary.Fields[0].Value != 3 {
+		t.Errorf("unexpected summary fields %v", summary.Fields)
+	}
+	if summary.Level != simplelogger.LevelInfo || summary.Prefix != "my-app" {
+		t.Errorf("unexpected summary level %s and prefix %q", summary.Level, summary.Prefix)
+	}
+}
+
+func TestDeduplicatorComparesFields(t *testing.T) {
+	deduplicator := simplelogger.NewDeduplicator(time.Hour)
+	emitted := newEmitted()
+
+	for _, url := range []string{"a", "b", "c"} {
+		if !deduplicator.Filter(newEntry("request failed", "url", url), emitted.emit) {
+			t.Errorf("entry with url=%s was dropped", url)
+		}
+	}
+
+	if deduplicator.Filter(newEntry("request failed", "url", "c"), emitted.emit) {
+		t.Error("repetition with the same fields was not dropped")
+	}
+
+	group := simplelogger.Group("request", "id", 1)
+	otherGroup := simplelogger.Group("request", "id", 2)
+	if !deduplicator.Filter(&simplelogger.Entry{Message: "grouped", Fields: []simplelogger.Field{group}, Time: time.Now()}, emitted.emit) ||
+		!deduplicator.Filter(&simplelogger.Entry{Message: "grouped", Fields: []simplelogger.Field{otherGroup}, Time: time.Now()}, emitted.emit) {
+		t.Error("entries with different grouped fields were dropped")
+	}
+
+	if deduplicator.Filter(newEntry("level"), emitted.emit) == false {
+		t.Fatal("the first entry was dropped")
+	}
+	warning := newEntry("level")
+	warning.Level = simplelogger.LevelWarn
+	if !deduplicator.Filter(warning, emitted.emit) {
+		t.Error("entry with a different level was dropped")
+	}
+}
+
+func TestDeduplicatorWindow(t *testing.T) {
+	deduplicator := simplelogger.NewDeduplicator(50 * time.Millisecond)
+	emitted := newEmitted()
+
+	deduplicator.Filter(newEntry("request failed"), emitted.emit)
+	deduplicator.Filter(newEntry("request failed"), emitted.emit)
+	deduplicator.Filter(newEntry("request failed"), emitted.emit)
+
+	// the timer writes the summary when the window has passed
+	if summary := emitted.next(t); summary.Message != "last message repeated 2 times" {
+		t.Errorf("unexpected summary %q", summary.Message)
+	}
+
+	if !deduplicator.Filter(newEntry("request failed"), emitted.emit) {
+		t.Error("entry after the window was dropped")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	emitted.none(t)
+}
+
+func TestDeduplicatorPerPrefix(t *testing.T) {
+	deduplicator := simplelogger.NewDeduplicator(time.Hour)
+	emitted := newEmitted()
+
+	otherEntry := newEntry("request failed")
+	otherEntry.Prefix = "my-app/other"
+
+	if !deduplicator.Filter(newEntry("request failed"), emitted.emit) || !deduplicator.Filter(otherEntry, emitted.emit) {
+		t.Error("identical entries of different prefixes were dropped")
+	}
+}
+
+func TestDeduplicatorLogger(t *testing.T) {
+	sink := &entrySink{}
+	logger := simplelogger.New("dedup", true, false)
+	t.Cleanup(func() { simplelogger.Unregister(logger) })
+	logger.Sinks = []simplelogger.Sink{sink}
+	logger.Filters = []simplelogger.Filter{simplelogger.NewDeduplicator(time.Hour)}
+
+	logger.Info("request failed", "url", "a")
+	logger.Info("request failed", "url", "b")
+	logger.Info("request failed", "url", "b")
+	logger.Info("done")
+
+	expected := []string{"request failed", "request failed", "last message repeated 1 times", "done"}
+	messages := sink.messages()
+	if len(messages) != len(expected) {
+		t.Fatalf("expected messages %q, got %q", expected, messages)
+	}
+	for index := range expected {
+		if messages[index] != expected[index] {
+			t.Errorf("expected messages %q, got %q", expected, messages)
+			break
+		}
+	}
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	rateLimiter := simplelogger.NewRateLimiter(0, 3)
+	emitted := newEmitted()
+
+	for index := 0; index < 3; index++ {
+		if !rateLimiter.Filter(newEntry("message"), emitted.emit) {
+			t.Fatalf("entry %d of the burst was dropped", index+1)
+		}
+	}
+
+	for index := 0; index < 5; index++ {
+		if rateLimiter.Filter(newEntry("message"), emitted.emit) {
+			t.Fatalf("entry %d after the burst was not dropped", index+1)
+		}
+	}
+	emitted.none(t)
+
+	otherEntry := newEntry("message")
+	otherEntry.Prefix = "my-app/other"
+	if !rateLimiter.Filter(otherEntry, emitted.emit) {
+		t.Error("another prefix shares the bucket")
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	rateLimiter := simplelogger.NewRateLimiter(20, 1)
+	emitted := newEmitted()
+
+	rateLimiter.Filter(newEntry("message"), emitted.emit)
+	if rateLimiter.Filter(newEntry("message"), emitted.emit) || rateLimiter.Filter(newEntry("message"), emitted.emit) {
+		t.Fatal("entries without tokens were not dropped")
+	}
+
+	// 20 tokens per second refill the bucket after 50ms
+	time.Sleep(200 * time.Millisecond)
+
+	if !rateLimiter.Filter(newEntry("message"), emitted.emit) {
+		t.Fatal("the bucket was not refilled")
+	}
+
+	summary := emitted.next(t)
+	if summary.Message != "rate limit exceeded, dropped 2 messages" || summary.Level != simplelogger.LevelWarn {
+		t.Errorf("unexpected summary [%s] %q", summary.Level, summary.Message)
+	}
+	if len(summary.Fields) != 1 || summary.Fields[0].Key != "dropped" || summary.Fields[0].Value != 2 {
+		t.Errorf("unexpected summary fields %v", summary.Fields)
+	}
+
+	// the burst caps the refilled tokens
+	if rateLimiter.Filter(newEntry("message"), emitted.emit) {
+		t.Error("the bucket holds more tokens than the burst")
+	}
+}
+
+func TestRateLimiterSetLimit(t *testing.T) {
+	rateLimiter := simplelogger.NewRateLimiter(0, 1)
+	rateLimiter.SetLimit("my-app", 0, 2)
+	rateLimiter.SetLimit("my-app/poller", 0, 4)
+
+	testCases := []struct {
+		burst  int
+		prefix string
+	}{
+		{burst: 2, prefix: "my-app"},
+		{burst: 2, prefix: "my-app/client"},
+		{burst: 4, prefix: "my-app/poller"},
+		{burst: 4, prefix: "my-app/poller/worker"},
+		{burst: 2, prefix: "my-app/pollerx"},
+		{burst: 1, prefix: "my-application"},
+		{burst: 1, prefix: "other"},
+	}
+
+	for _, testCase := range testCases {
+		passed := 0
+		for index := 0; index < 10; index++ {
+			entry := newEntry("message")
+			entry.Prefix = testCase.prefix
+			if rateLimiter.Filter(entry, func(*simplelogger.Entry) {}) {
+				passed++
+			}
+		}
+
+		if passed != testCase.burst {
+			t.Errorf("%s: expected a burst of %d, got %d", testCase.prefix, testCase.burst, passed)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// redactedText replaces secrets in the output
+const redactedText = "[REDACTED]"
+
+// builtinRedactionPatterns match GitHub tokens, authorization headers, values
+// which consist of "Bearer <token>" and the user info of URLs with a password.
+// If a pattern has a group named "secret", only the group is masked.
+var builtinRedactionPatterns = []string{
+	`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}\b`,
+	`\bgithub_pat_[A-Za-z0-9_]{22,}\b`,
+	`(?i)^\s*bearer\s+(?P<secret>[A-Za-z0-9\-._~+/]+=*)\s*$`,
+	`(?i)\bauthorization:\s*(?:basic|bearer|token)\s+(?P<secret>\S+)`,
+	`(?i)\b[a-z][a-z0-9+.\-]*://(?P<secret>[^/?#@:\s]*:[^/?#@\s]*)@`,
+}
+
+// builtinRedactedFields are the field names whose values are always masked
+var builtinRedactedFields = []string{"authorization", "password", "token"}
+
+// Redactor masks secrets in the messages, fields and errors of entries before
+// they are passed to hooks and sinks
+type Redactor struct {
+	fields   map[string]bool
+	mutex    sync.RWMutex
+	patterns []*regexp.Regexp
+}
+
+// redactedError is an error with masked messages which keeps the structure of
+// the original error chain
+type redactedError struct {
+	causes  []error
+	message string
+}
+
+// DefaultRedactor is used by all loggers without a Redactor and knows the
+// built-in patterns and field names
+var DefaultRedactor = NewRedactor()
+
+// NewRedactor returns a new instance of Redactor with the built-in patterns
+// and field names. An empty &Redactor{} masks nothing.
+func NewRedactor() *Redactor {
+	redactor := &Redactor{}
+
+	for _, pattern := range builtinRedactionPatterns {
+		redactor.patterns = append(redactor.patterns, regexp.MustCompile(pattern))
+	}
+
+	redactor.AddField(builtinRedactedFields...)
+
+	return redactor
+}
+
+// AddPattern adds a regular expression whose matches are masked. If it has a
+// group named "secret", only the group is masked.
+func (redactor *Redactor) AddPattern(pattern string) error {
+	regExp, compileError := regexp.Compile(pattern)
+	if compileError != nil {
+		return compileError
+	}
+
+	redactor.mutex.Lock()
+	defer redactor.mutex.Unlock()
+
+	redactor.patterns = append(redactor.patterns, regExp)
+	return nil
+}
+
+// AddField adds field names (case insensitive) whose values are always masked
+func (redactor *Redactor) AddField(names ...string) {
+	redactor.mutex.Lock()
+	defer redactor.mutex.Unlock()
+
+	if redactor.fields == nil {
+		redactor.fields = map[string]bool{}
+	}
+
+	for _, name := range names {
+		redactor.fields[strings.ToLower(name)] = true
+	}
+}
+
+// RedactString returns the text with all matches of the patterns masked
+func (redactor *Redactor) RedactString(text string) string {
+	redactor.mutex.RLock()
+	defer redactor.mutex.RUnlock()
+
+	for _, regExp := range redactor.patterns {
+		text = redactMatches(regExp, text)
+	}
+
+	return text
+}
+
+func redactMatches(regExp *regexp.Regexp, text string) string {
+	secretIndex := regExp.SubexpIndex("secret")
+	matches := regExp.FindAllStringSubmatchIndex(text, -1)
+
+	if len(matches) == 0 {
+		return text
+	}
+
+	var builder strings.Builder
+	lastEnd := 0
+
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		if secretIndex != -1 && match[2*secretIndex] != -1 {
+			start, end = match[2*secretIndex], match[2*secretIndex+1]
+		}
+
+		builder.WriteString(text[lastEnd:start])
+		builder.WriteString(redactedText)
+		lastEnd = end
+	}
+
+	builder.WriteString(text[lastEnd:])
+	return builder.String()
+}
+
+// Redact masks the secrets in the message, fields and errors of the entry
+func (redactor *Redactor) Redact(entry *Entry) {
+	entry.Message = redactor.RedactString(entry.Message)
+	entry.Fields = redactor.redactFields(entry.Fields)
+
+	for index, err := range entry.Errors {
+		entry.Errors[index] = redactor.redactError(err)
+	}
+}
+
+func (redactor *Redactor) isRedactedField(name string) bool {
+	redactor.mutex.RLock()
+	defer redactor.mutex.RUnlock()
+
+	return redactor.fields[strings.ToLower(name)]
+}
+
+func (redactor *Redactor) redactFields(fields []Field) []Field {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	redactedFields := make([]Field, 0, len(fields))
+
+	for _, field := range fields {
+		redactedFields = append(redactedFields, Field{Key: field.Key, Value: redactor.redactValue(field.Key, field.Value)})
+	}
+
+	return redactedFields
+}
+
+// redactValue masks the value if the key is a redacted field name or if its
+// text contains secrets. Values without secrets are returned unchanged.
+func (redactor *Redactor) redactValue(key string, value interface{}) interface{} {
+	if redactor.isRedactedField(key) {
+		return redactedText
+	}
+
+	var text string
+
+	switch typedValue := value.(type) {
+	case nil:
+		return nil
+	case []Field:
+		return redactor.redactFields(typedValue)
+	case string:
+		return redactor.RedactString(typedValue)
+	case error:
+		return redactor.redactError(typedValue)
+	case fmt.Stringer:
+		text = fmt.Sprint(typedValue)
+	default:
+		text = fmt.Sprintf("%+v", typedValue)
+	}
+
+	if maskedText := redactor.RedactString(text); maskedText != text {
+		return maskedText
+	}
+
+	return value
+}
+
+// redactError returns the error unchanged if its chain contains no secrets and
+// otherwise an error with the same structure and masked messages
+func (redactor *Redactor) redactError(err error) error {
+	redactedErr, _ := redactor.redactErrorChain(err)
+	return redactedErr
+}
+
+// redactErrorChain masks the messages of the error and its causes and reports
+// whether any of them contained a secret
+func (redactor *Redactor) redactErrorChain(err error) (error, bool) {
+	message := errorText(err)
+	redactedMessage := redactor.RedactString(message)
+	changed := redactedMessage != message
+
+	var causes []error
+	for _, cause := range unwrapAll(err) {
+		redactedCause, causeChanged := redactor.redactErrorChain(cause)
+		changed = changed || causeChanged
+		causes = append(causes, redactedCause)
+	}
+
+	if !changed {
+		return err, false
+	}
+
+	return &redactedError{causes: causes, message: redactedMessage}, true
+}
+
+func (err *redactedError) Error() string {
+	return err.message
+}
+
+func (err *redactedError) Unwrap() []error {
+	return err.causes
+}
+
+// AddRedactionPattern adds a pattern to the DefaultRedactor
+func AddRedactionPattern(pattern string) error {
+	return DefaultRedactor.AddPattern(pattern)
+}
+
+// AddRedactedField adds field names to the DefaultRedactor
+func AddRedactedField(names ...string) {
+	DefaultRedactor.AddField(names...)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"strings"
+	"sync"
+)
+
+// Registry keeps track of loggers by their prefix
+type Registry struct {
+	loggers []*SimpleLogger
+	mutex   sync.Mutex
+}
+
+var (
+	// DefaultRegistry contains all loggers created with New and Extend
+	DefaultRegistry = NewRegistry()
+
+	// childMutex makes sure NewChild creates every logger only once
+	childMutex sync.Mutex
+)
+
+// NewRegistry returns a new instance of Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds the logger to the registry
+func (registry *Registry) Register(logger *SimpleLogger) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for _, registeredLogger := range registry.loggers {
+		if registeredLogger == logger {
+			return
+		}
+	}
+
+	registry.loggers = append(registry.loggers, logger)
+}
+
+// Unregister removes the logger and all its registered children from the
+// registry, e.g. when a logger created per request is no longer needed
+func (registry *Registry) Unregister(logger *SimpleLogger) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	loggers := registry.loggers[:0]
+	for _, registeredLogger := range registry.loggers {
+		if !isDescendant(registeredLogger, logger) {
+			loggers = append(loggers, registeredLogger)
+		}
+	}
+
+	for index := len(loggers); index < len(registry.loggers); index++ {
+		registry.loggers[index] = nil
+	}
+
+	registry.loggers = loggers
+}
+
+// Loggers returns all registered loggers
+func (registry *Registry) Loggers() []*SimpleLogger {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	return append([]*SimpleLogger{}, registry.loggers...)
+}
+
+// Lookup returns the first registered logger with the prefix or nil if there is none
+func (registry *Registry) Lookup(prefix string) *SimpleLogger {
+	for _, logger := range registry.Loggers() {
+		if logger.Prefix == prefix {
+			return logger
+		}
+	}
+
+	return nil
+}
+
+// Subtree returns all registered loggers in the namespace, i.e. the loggers with
+// the namespace as prefix and their children (e.g. "gh-open" and "gh-open/gitclient")
+func (registry *Registry) Subtree(namespace string) []*SimpleLogger {
+	var loggers []*SimpleLogger
+
+	for _, logger := range registry.Loggers() {
+		if inNamespace(logger.Prefix, namespace) {
+			loggers = append(loggers, logger)
+		}
+	}
+
+	return loggers
+}
+
+// Enable enables all loggers in the namespace
+func (registry *Registry) Enable(namespace string) {
+	for _, logger := range registry.Subtree(namespace) {
+		logger.SetEnabled(true)
+	}
+}
+
+// Disable disables all loggers in the namespace
+func (registry *Registry) Disable(namespace string) {
+	for _, logger := range registry.Subtree(namespace) {
+		logger.SetEnabled(false)
+	}
+}
+
+// SetLevel sets the level of all loggers in the namespace
+func (registry *Registry) SetLevel(namespace string, level Level) {
+	for _, logger := range registry.Subtree(namespace) {
+		logger.SetLevel(level)
+	}
+}
+
+// Configure applies a DEBUG specification (e.g. "gh-open*=debug") to all
+// registered loggers, as if they were created with it
+func (registry *Registry) Configure(spec string) {
+	matcher := NewMatcher(spec)
+
+	for _, logger := range registry.Loggers() {
+		logger.configure(matcher)
+	}
+}
+
+// isDescendant returns whether the logger is the ancestor or one of its children
+func isDescendant(logger *SimpleLogger, ancestor *SimpleLogger) bool {
+	for current := logger; current != nil; current = current.parent {
+		if current == ancestor {
+			return true
+		}
+	}
+
+	return false
+}
+
+func inNamespace(prefix string, namespace string) bool {
+	return prefix == namespace || strings.HasPrefix(prefix, namespace+"/")
+}
+
+// Lookup returns the first logger in the DefaultRegistry with the prefix or nil
+func Lookup(prefix string) *SimpleLogger {
+	return DefaultRegistry.Lookup(prefix)
+}
+
+// Unregister removes the logger and its children from the DefaultRegistry
+func Unregister(logger *SimpleLogger) {
+	DefaultRegistry.Unregister(logger)
+}
+
+// NewChild returns the logger "<namespace>/<name>" of the DefaultRegistry. If
+// it doesn't exist yet, it is created with Extend from the registered logger
+// namespace, which itself is created with New(namespace, enabled, true) if
+// needed. If enabled is true, the child is enabled regardless of DEBUG.
+func NewChild(namespace string, name string, enabled bool) *SimpleLogger {
+	childMutex.Lock()
+	defer childMutex.Unlock()
+
+	child := Lookup(namespace + "/" + name)
+
+	if child == nil {
+		parent := Lookup(namespace)
+		if parent == nil {
+			parent = New(namespace, enabled, true)
+		}
+
+		child = parent.Extend(name)
+	}
+
+	if enabled == true {
+		child.SetEnabled(true)
+	}
+
+	return child
+}
+
+// Enable enables all loggers in the namespace of the DefaultRegistry
+func Enable(namespace string) {
+	DefaultRegistry.Enable(namespace)
+}
+
+// Disable disables all loggers in the namespace of the DefaultRegistry
+func Disable(namespace string) {
+	DefaultRegistry.Disable(namespace)
+}
+
+// Configure applies a DEBUG specification to all loggers of the DefaultRegistry
+func Configure(spec string) {
+	DefaultRegistry.Configure(spec)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// WatchSignals reconfigures all loggers of the DefaultRegistry at runtime:
+// on SIGHUP the specification is read again from specFile (or, if specFile is
+// empty, from the DEBUG environment variable), on SIGUSR1 all loggers are
+// enabled with level trace. The returned function stops watching.
+// Signals are only supported on unix systems.
+func WatchSignals(specFile string) func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	if len(reloadSignals) > 0 {
+		signal.Notify(signals, reloadSignals...)
+	}
+
+	go func() {
+		for {
+			select {
+			case receivedSignal := <-signals:
+				handleSignal(receivedSignal, specFile)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+func handleSignal(receivedSignal os.Signal, specFile string) {
+	if !isReloadSignal(receivedSignal) {
+		Configure("*")
+		return
+	}
+
+	if specFile == "" {
+		Configure(os.Getenv("DEBUG"))
+		return
+	}
+
+	spec, readError := os.ReadFile(specFile)
+	if readError != nil {
+		return
+	}
+
+	Configure(strings.TrimSpace(string(spec)))
+}
@@ -0,0 +1,108 @@
+//go:build unix
+
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+)
+
+// waitFor polls the condition until it is true or a timeout passes
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return
+		}
+	}
+
+	t.Fatal("timed out waiting for the signal to be handled")
+}
+
+func sendSignal(t *testing.T, signal syscall.Signal) {
+	t.Helper()
+
+	if killError := syscall.Kill(os.Getpid(), signal); killError != nil {
+		t.Fatal(killError)
+	}
+}
+
+func TestWatchSignalsSpecFile(t *testing.T) {
+	logger := simplelogger.New("reload", false, false)
+	t.Cleanup(func() { simplelogger.Unregister(logger) })
+
+	specFile := filepath.Join(t.TempDir(), "debug")
+	if writeError := os.WriteFile(specFile, []byte("reload=warn\n"), 0o644); writeError != nil {
+		t.Fatal(writeError)
+	}
+
+	stop := simplelogger.WatchSignals(specFile)
+	defer stop()
+
+	sendSignal(t, syscall.SIGHUP)
+	waitFor(t, func() bool {
+		return logger.IsEnabled() && logger.GetLevel() == simplelogger.LevelWarn
+	})
+
+	// an unreadable specification file leaves the loggers unchanged
+	os.Remove(specFile)
+	logger.SetLevel(simplelogger.LevelError)
+
+	sendSignal(t, syscall.SIGHUP)
+	time.Sleep(100 * time.Millisecond)
+
+	if !logger.IsEnabled() || logger.GetLevel() != simplelogger.LevelError {
+		t.Error("a missing specification file changed the logger")
+	}
+}
+
+func TestWatchSignalsEnvironment(t *testing.T) {
+	logger := simplelogger.New("reload", false, false)
+	t.Cleanup(func() { simplelogger.Unregister(logger) })
+	t.Setenv("DEBUG", "reload=info")
+
+	stop := simplelogger.WatchSignals("")
+	defer stop()
+
+	sendSignal(t, syscall.SIGHUP)
+	waitFor(t, func() bool {
+		return logger.IsEnabled() && logger.GetLevel() == simplelogger.LevelInfo
+	})
+}
+
+func TestWatchSignalsEnableAll(t *testing.T) {
+	logger := simplelogger.New("reload", false, false)
+	t.Cleanup(func() { simplelogger.Unregister(logger) })
+	logger.SetLevel(simplelogger.LevelError)
+
+	stop := simplelogger.WatchSignals("")
+	defer stop()
+
+	sendSignal(t, syscall.SIGUSR1)
+	waitFor(t, func() bool {
+		return logger.IsEnabled() && logger.GetLevel() == simplelogger.LevelTrace
+	})
+}
@@ -0,0 +1,248 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var headerTimeRegExp = regexp.MustCompile(`^# .* log file, written by simplelogger .* on (\S+)\n$`)
+
+// RotatingFile is a writer for log files which starts a new file when the
+// current one reaches MaxSize bytes or is older than MaxAge. The old files are
+// kept as "<Filename>.1" (the newest) to "<Filename>.<MaxBackups>" and are
+// gzip-compressed if Compress is set. Every file starts with a header line
+// naming the prefix and the simplelogger version.
+type RotatingFile struct {
+	Compress   bool
+	Filename   string
+	MaxAge     time.Duration
+	MaxBackups int
+	MaxSize    int64
+	Prefix     string
+
+	file     *os.File
+	mutex    sync.Mutex
+	openedAt time.Time
+	size     int64
+}
+
+// NewRotatingFile returns a new instance of RotatingFile which rotates at 10 MB
+// and keeps 3 old files
+func NewRotatingFile(filename string, prefix string) *RotatingFile {
+	return &RotatingFile{
+		Filename:   filename,
+		MaxBackups: 3,
+		MaxSize:    10 * 1024 * 1024,
+		Prefix:     prefix,
+	}
+}
+
+// Write writes the buffer to the current file and rotates it before if needed
+func (rotatingFile *RotatingFile) Write(buffer []byte) (int, error) {
+	rotatingFile.mutex.Lock()
+	defer rotatingFile.mutex.Unlock()
+
+	if rotatingFile.file == nil {
+		if openError := rotatingFile.open(); openError != nil {
+			return 0, openError
+		}
+	}
+
+	if rotatingFile.needsRotation(int64(len(buffer))) {
+		if rotateError := rotatingFile.rotate(); rotateError != nil {
+			return 0, rotateError
+		}
+	}
+
+	written, writeError := rotatingFile.file.Write(buffer)
+	rotatingFile.size += int64(written)
+
+	return written, writeError
+}
+
+// Rotate closes the current file, moves it to the backups and starts a new one
+func (rotatingFile *RotatingFile) Rotate() error {
+	rotatingFile.mutex.Lock()
+	defer rotatingFile.mutex.Unlock()
+
+	return rotatingFile.rotate()
+}
+
+// Close closes the current file
+func (rotatingFile *RotatingFile) Close() error {
+	rotatingFile.mutex.Lock()
+	defer rotatingFile.mutex.Unlock()
+
+	return rotatingFile.close()
+}
+
+func (rotatingFile *RotatingFile) needsRotation(writeSize int64) bool {
+	if rotatingFile.MaxSize > 0 && rotatingFile.size > 0 && rotatingFile.size+writeSize > rotatingFile.MaxSize {
+		return true
+	}
+
+	return rotatingFile.MaxAge > 0 && time.Since(rotatingFile.openedAt) > rotatingFile.MaxAge
+}
+
+func (rotatingFile *RotatingFile) open() error {
+	file, openError := os.OpenFile(rotatingFile.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if openError != nil {
+		return openError
+	}
+
+	fileInfo, statError := file.Stat()
+	if statError != nil {
+		file.Close()
+		return statError
+	}
+
+	rotatingFile.file = file
+	rotatingFile.size = fileInfo.Size()
+
+	if rotatingFile.size > 0 {
+		rotatingFile.openedAt = fileStartTime(rotatingFile.Filename, fileInfo.ModTime())
+		return nil
+	}
+
+	rotatingFile.openedAt = time.Now()
+	header := fmt.Sprintf("# %s log file, written by simplelogger %s on %s\n", rotatingFile.Prefix, version, rotatingFile.openedAt.Format(time.RFC3339))
+	written, writeError := file.WriteString(header)
+	rotatingFile.size += int64(written)
+
+	return writeError
+}
+
+// fileStartTime returns the time from the header line of an existing log file,
+// so its age survives restarts, or the fallback if the file has no header
+func fileStartTime(fileName string, fallback time.Time) time.Time {
+	file, openError := os.Open(fileName)
+	if openError != nil {
+		return fallback
+	}
+
+	defer file.Close()
+
+	firstLine, _ := bufio.NewReader(io.LimitReader(file, 1024)).ReadString('\n')
+	headerMatches := headerTimeRegExp.FindStringSubmatch(firstLine)
+	if len(headerMatches) != 2 {
+		return fallback
+	}
+
+	startTime, parseError := time.Parse(time.RFC3339, headerMatches[1])
+	if parseError != nil {
+		return fallback
+	}
+
+	return startTime
+}
+
+func (rotatingFile *RotatingFile) close() error {
+	if rotatingFile.file == nil {
+		return nil
+	}
+
+	closeError := rotatingFile.file.Close()
+	rotatingFile.file = nil
+
+	return closeError
+}
+
+func (rotatingFile *RotatingFile) backupName(number int) string {
+	backupName := fmt.Sprintf("%s.%d", rotatingFile.Filename, number)
+	if rotatingFile.Compress {
+		backupName += ".gz"
+	}
+	return backupName
+}
+
+func (rotatingFile *RotatingFile) rotate() error {
+	if closeError := rotatingFile.close(); closeError != nil {
+		return closeError
+	}
+
+	if rotatingFile.MaxBackups <= 0 {
+		if removeError := os.Remove(rotatingFile.Filename); removeError != nil && !os.IsNotExist(removeError) {
+			return removeError
+		}
+		return rotatingFile.open()
+	}
+
+	os.Remove(rotatingFile.backupName(rotatingFile.MaxBackups))
+
+	for number := rotatingFile.MaxBackups - 1; number >= 1; number-- {
+		renameError := os.Rename(rotatingFile.backupName(number), rotatingFile.backupName(number+1))
+		if renameError != nil && !os.IsNotExist(renameError) {
+			return renameError
+		}
+	}
+
+	var moveError error
+	if rotatingFile.Compress {
+		moveError = compressFile(rotatingFile.Filename, rotatingFile.backupName(1))
+	} else {
+		moveError = os.Rename(rotatingFile.Filename, rotatingFile.backupName(1))
+	}
+
+	if moveError != nil && !os.IsNotExist(moveError) {
+		return moveError
+	}
+
+	return rotatingFile.open()
+}
+
+// compressFile writes the gzip-compressed source file to the target file and
+// removes the source file
+func compressFile(sourceName string, targetName string) error {
+	source, openError := os.Open(sourceName)
+	if openError != nil {
+		return openError
+	}
+
+	defer source.Close()
+
+	target, createError := os.Create(targetName)
+	if createError != nil {
+		return createError
+	}
+
+	gzipWriter := gzip.NewWriter(target)
+
+	if _, copyError := io.Copy(gzipWriter, source); copyError != nil {
+		target.Close()
+		return copyError
+	}
+
+	if closeError := gzipWriter.Close(); closeError != nil {
+		target.Close()
+		return closeError
+	}
+
+	if closeError := target.Close(); closeError != nil {
+		return closeError
+	}
+
+	return os.Remove(sourceName)
+}
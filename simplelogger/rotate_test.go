@@ -0,0 +1,227 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger_test
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+)
+
+// readLogFile returns the content of a (gzip-compressed) log file
+func readLogFile(t *testing.T, fileName string) string {
+	t.Helper()
+
+	file, openError := os.Open(fileName)
+	if openError != nil {
+		t.Fatal(openError)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(fileName, ".gz") {
+		gzipReader, gzipError := gzip.NewReader(file)
+		if gzipError != nil {
+			t.Fatal(gzipError)
+		}
+		reader = gzipReader
+	}
+
+	content, readError := io.ReadAll(reader)
+	if readError != nil {
+		t.Fatal(readError)
+	}
+
+	return string(content)
+}
+
+// logLine returns a line of 50 bytes
+func logLine(number int) string {
+	return fmt.Sprintf("%-49s\n", fmt.Sprintf("line %d", number))
+}
+
+func writeLines(t *testing.T, rotatingFile *simplelogger.RotatingFile, from int, to int) {
+	t.Helper()
+
+	for number := from; number <= to; number++ {
+		if _, writeError := rotatingFile.Write([]byte(logLine(number))); writeError != nil {
+			t.Fatal(writeError)
+		}
+	}
+}
+
+// expectLines checks that the log file starts with the header and contains exactly the lines
+func expectLines(t *testing.T, fileName string, numbers ...int) {
+	t.Helper()
+
+	content := readLogFile(t, fileName)
+	header, lines, _ := strings.Cut(content, "\n")
+
+	if !strings.HasPrefix(header, "# test log file, written by simplelogger ") {
+		t.Errorf("%s has no header: %q", filepath.Base(fileName), header)
+	}
+
+	var expected strings.Builder
+	for _, number := range numbers {
+		expected.WriteString(logLine(number))
+	}
+
+	if lines != expected.String() {
+		t.Errorf("%s: expected lines %v, got %q", filepath.Base(fileName), numbers, lines)
+	}
+}
+
+func expectMissing(t *testing.T, fileNames ...string) {
+	t.Helper()
+
+	for _, fileName := range fileNames {
+		if _, statError := os.Stat(fileName); !os.IsNotExist(statError) {
+			t.Errorf("%s exists", filepath.Base(fileName))
+		}
+	}
+}
+
+func newRotatingFile(t *testing.T) *simplelogger.RotatingFile {
+	t.Helper()
+
+	rotatingFile := simplelogger.NewRotatingFile(filepath.Join(t.TempDir(), "test.log"), "test")
+	t.Cleanup(func() { rotatingFile.Close() })
+
+	return rotatingFile
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	rotatingFile := newRotatingFile(t)
+	rotatingFile.MaxBackups = 2
+	// the header (~70 bytes) and two lines fit into one file
+	rotatingFile.MaxSize = 200
+
+	writeLines(t, rotatingFile, 1, 7)
+
+	expectLines(t, rotatingFile.Filename, 7)
+	expectLines(t, rotatingFile.Filename+".1", 5, 6)
+	expectLines(t, rotatingFile.Filename+".2", 3, 4)
+	expectMissing(t, rotatingFile.Filename+".3")
+}
+
+func TestRotatingFileCompress(t *testing.T) {
+	rotatingFile := newRotatingFile(t)
+	rotatingFile.Compress = true
+	rotatingFile.MaxBackups = 2
+	rotatingFile.MaxSize = 200
+
+	writeLines(t, rotatingFile, 1, 5)
+
+	expectLines(t, rotatingFile.Filename, 5)
+	expectLines(t, rotatingFile.Filename+".1.gz", 3, 4)
+	expectLines(t, rotatingFile.Filename+".2.gz", 1, 2)
+	expectMissing(t, rotatingFile.Filename+".1", rotatingFile.Filename+".3.gz")
+}
+
+func TestRotatingFileWithoutBackups(t *testing.T) {
+	rotatingFile := newRotatingFile(t)
+	rotatingFile.MaxBackups = 0
+
+	writeLines(t, rotatingFile, 1, 2)
+	if rotateError := rotatingFile.Rotate(); rotateError != nil {
+		t.Fatal(rotateError)
+	}
+	writeLines(t, rotatingFile, 3, 3)
+
+	expectLines(t, rotatingFile.Filename, 3)
+	expectMissing(t, rotatingFile.Filename+".1")
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	rotatingFile := newRotatingFile(t)
+	rotatingFile.MaxAge = 50 * time.Millisecond
+
+	writeLines(t, rotatingFile, 1, 2)
+	time.Sleep(100 * time.Millisecond)
+	writeLines(t, rotatingFile, 3, 3)
+
+	expectLines(t, rotatingFile.Filename, 3)
+	expectLines(t, rotatingFile.Filename+".1", 1, 2)
+}
+
+func TestRotatingFileAgeAfterReopening(t *testing.T) {
+	testCases := []struct {
+		content string
+		modTime time.Time
+		name    string
+		rotates bool
+	}{
+		{
+			// the file was written a moment ago, but started two hours ago
+			content: "# test log file, written by simplelogger 0.0.3 on " + time.Now().Add(-2*time.Hour).Format(time.RFC3339) + "\n" + logLine(1),
+			modTime: time.Now(),
+			name:    "old header",
+			rotates: true,
+		},
+		{
+			content: "# test log file, written by simplelogger 0.0.3 on " + time.Now().Add(-time.Minute).Format(time.RFC3339) + "\n" + logLine(1),
+			modTime: time.Now().Add(-2 * time.Hour),
+			name:    "recent header",
+			rotates: false,
+		},
+		{
+			content: logLine(1),
+			modTime: time.Now().Add(-2 * time.Hour),
+			name:    "old file without header",
+			rotates: true,
+		},
+		{
+			content: logLine(1),
+			modTime: time.Now(),
+			name:    "recent file without header",
+			rotates: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		fileName := filepath.Join(t.TempDir(), "test.log")
+		if writeError := os.WriteFile(fileName, []byte(testCase.content), 0644); writeError != nil {
+			t.Fatal(writeError)
+		}
+		if timesError := os.Chtimes(fileName, testCase.modTime, testCase.modTime); timesError != nil {
+			t.Fatal(timesError)
+		}
+
+		rotatingFile := simplelogger.NewRotatingFile(fileName, "test")
+		rotatingFile.MaxAge = time.Hour
+		writeLines(t, rotatingFile, 2, 2)
+		rotatingFile.Close()
+
+		_, statError := os.Stat(fileName + ".1")
+		if rotated := statError == nil; rotated != testCase.rotates {
+			t.Errorf("%s: expected rotation %t, got %t", testCase.name, testCase.rotates, rotated)
+		}
+
+		// appending keeps the existing content and doesn't add another header
+		if !testCase.rotates && readLogFile(t, fileName) != testCase.content+logLine(2) {
+			t.Errorf("%s: unexpected content %q", testCase.name, readLogFile(t, fileName))
+		}
+	}
+}
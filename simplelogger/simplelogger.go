@@ -19,65 +19,460 @@ package simplelogger
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"strings"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
 )
 
 const version = "0.0.3"
 
-// SimpleLogger is a configuration struct for the logger
+var (
+	lastLogged       = map[string]time.Time{}
+	lastLoggedMutex  sync.Mutex
+	reportDebugError sync.Once
+)
+
+// SimpleLogger is a configuration struct for the logger. It is safe to use
+// from multiple goroutines, use SetEnabled and SetLevel to change the enabled
+// state and the level while other goroutines are logging.
 type SimpleLogger struct {
-	Enabled bool
-	Prefix  string
+	Enabled   bool
+	ErrOut    io.Writer
+	Fields    []Field
+	Filters   []Filter
+	Formatter Formatter
+	Level     Level
+	MaxLength int
+	Out       io.Writer
+	Prefix    string
+	Redactor  *Redactor
+	Sinks     []Sink
+
+	// ReportCaller adds the file, line and function of the caller to every entry
+	ReportCaller bool
+	// StackTraces adds the stack trace to every entry with level error
+	StackTraces bool
+
+	async            *asyncQueue
+	checkEnvironment bool
+	defaultEnabled   bool
+	hooks            []Hook
+	inheritEnabled   bool
+	inheritLevel     bool
+	mutex            sync.RWMutex
+	parent           *SimpleLogger
+}
+
+// Hook receives every entry of a logger and its children, even if the logger
+// is disabled or the entry is below its level
+type Hook interface {
+	Fire(entry *Entry)
+}
+
+// Filter decides whether an entry is written. emit writes an additional entry
+// (e.g. a summary of dropped entries) without passing it through the filters.
+type Filter interface {
+	Filter(entry *Entry, emit func(entry *Entry)) bool
+}
+
+// Sink receives the entries of a logger which passed the enabled and level
+// checks. If a logger has sinks, its entries are not written to Out/ErrOut.
+type Sink interface {
+	WriteEntry(entry *Entry) error
+}
+
+// environmentMatcher returns the matcher for the DEBUG environment variable.
+// Invalid patterns are reported once on stderr.
+func environmentMatcher() *Matcher {
+	matcher, parseError := ParseMatcher(os.Getenv("DEBUG"))
+
+	if parseError != nil {
+		reportDebugError.Do(func() {
+			fmt.Fprintln(os.Stderr, "simplelogger: ignoring invalid DEBUG patterns:", parseError)
+		})
+	}
+
+	return matcher
 }
 
 // New returns a new instance of Logger
 func New(prefix string, enabled bool, checkEnvironment bool) *SimpleLogger {
+	var (
+		formatter    Formatter
+		maxLength    int
+		reportCaller bool
+	)
+
 	if checkEnvironment == true {
-		DEBUG := os.Getenv("DEBUG")
-		if strings.Contains(DEBUG, prefix) {
-			enabled = true
+		if envFormatter, formatterError := FormatterByName(os.Getenv("DEBUG_FORMAT")); formatterError == nil {
+			formatter = envFormatter
 		}
+
+		reportCaller, _ = strconv.ParseBool(os.Getenv("DEBUG_CALLER"))
+		maxLength, _ = strconv.Atoi(os.Getenv("DEBUG_MAX_LENGTH"))
 	}
 
+	logger := &SimpleLogger{
+		Enabled:          enabled,
+		ErrOut:           os.Stderr,
+		Formatter:        formatter,
+		Level:            LevelTrace,
+		MaxLength:        maxLength,
+		Out:              os.Stderr,
+		Prefix:           prefix,
+		ReportCaller:     reportCaller,
+		checkEnvironment: checkEnvironment,
+		defaultEnabled:   enabled,
+	}
+
+	if checkEnvironment == true {
+		logger.configure(environmentMatcher())
+	}
+
+	DefaultRegistry.Register(logger)
+
+	return logger
+}
+
+func (logger *SimpleLogger) newChild(prefix string) *SimpleLogger {
 	return &SimpleLogger{
-		Enabled: enabled,
-		Prefix:  prefix,
+		ErrOut:           logger.ErrOut,
+		Fields:           append([]Field{}, logger.Fields...),
+		Filters:          logger.Filters,
+		Formatter:        logger.Formatter,
+		Level:            logger.GetLevel(),
+		MaxLength:        logger.MaxLength,
+		Out:              logger.Out,
+		Prefix:           prefix,
+		Redactor:         logger.Redactor,
+		ReportCaller:     logger.ReportCaller,
+		Sinks:            logger.Sinks,
+		StackTraces:      logger.StackTraces,
+		checkEnvironment: logger.checkEnvironment,
+		inheritEnabled:   true,
+		inheritLevel:     true,
+		parent:           logger,
 	}
 }
 
-func bold(message string) string {
-	return fmt.Sprintf("\033[1m%s\033[0m", message)
+// Extend returns a child logger with the prefix "<prefix>/<name>". The child
+// inherits the writers, level, format and fields and is enabled while the parent
+// is enabled, unless the DEBUG environment variable says otherwise.
+func (logger *SimpleLogger) Extend(name string) *SimpleLogger {
+	prefix := name
+	if logger.Prefix != "" {
+		prefix = logger.Prefix + "/" + name
+	}
+
+	child := logger.newChild(prefix)
+
+	if child.checkEnvironment == true {
+		child.configure(environmentMatcher())
+	}
+
+	DefaultRegistry.Register(child)
+
+	return child
 }
 
-func red(message string) string {
-	return fmt.Sprintf("\033[91m%s\033[0m", message)
+// configure sets the enabled state and the level as if the logger was created
+// with the DEBUG specification of the matcher. Loggers which are not mentioned
+// in the specification get their initial state back.
+func (logger *SimpleLogger) configure(matcher *Matcher) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	if specLevel, found := matcher.Level(logger.Prefix); found {
+		logger.Enabled = true
+		logger.Level = specLevel
+		logger.inheritLevel = false
+		return
+	}
+
+	if matcher.Excludes(logger.Prefix) {
+		logger.Enabled = false
+		logger.inheritEnabled = false
+		return
+	}
+
+	// a child only follows its parent if the parent wasn't enabled by the
+	// specification, so "gh-open" doesn't enable "gh-open/gitclient"
+	logger.Enabled = logger.defaultEnabled
+	logger.inheritEnabled = logger.parent != nil && !matcher.Enabled(logger.parent.Prefix)
+	logger.inheritLevel = logger.parent != nil
+	logger.Level = LevelTrace
 }
 
-// Log logs one or more unformatted messages if the logger is enabled
-func (logger *SimpleLogger) Log(messages ...interface{}) {
-	if logger.Enabled == true {
-		fmt.Printf("%s %s", bold(logger.Prefix), fmt.Sprintln(messages...))
+// With returns a child logger with the same prefix which adds the key/value
+// pairs (e.g. "repo", name) to every message
+func (logger *SimpleLogger) With(keyvals ...interface{}) *SimpleLogger {
+	child := logger.newChild(logger.Prefix)
+	child.Fields = append(child.Fields, toFields(keyvals)...)
+	return child
+}
+
+// IsEnabled returns whether the logger or, for child loggers, its parent is enabled
+func (logger *SimpleLogger) IsEnabled() bool {
+	logger.mutex.RLock()
+	enabled := logger.Enabled
+	inheritEnabled := logger.inheritEnabled
+	logger.mutex.RUnlock()
+
+	if enabled == true {
+		return true
 	}
+
+	return inheritEnabled && logger.parent != nil && logger.parent.IsEnabled()
 }
 
-// Logf logs one or more formatted messages if the logger is enabled
-func (logger *SimpleLogger) Logf(format string, messages ...interface{}) {
-	if logger.Enabled == true {
-		fmt.Printf("%s %s\n", bold(logger.Prefix), fmt.Sprintf(format, messages...))
+// SetEnabled enables or disables the logger. A child logger no longer follows
+// its parent afterwards.
+func (logger *SimpleLogger) SetEnabled(enabled bool) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	logger.Enabled = enabled
+	logger.inheritEnabled = false
+}
+
+// GetLevel returns the minimum level of the logger. A child logger without its
+// own level (from SetLevel or DEBUG) uses the current level of its parent.
+func (logger *SimpleLogger) GetLevel() Level {
+	logger.mutex.RLock()
+	level := logger.Level
+	inheritLevel := logger.inheritLevel
+	logger.mutex.RUnlock()
+
+	if inheritLevel && logger.parent != nil {
+		return logger.parent.GetLevel()
 	}
+
+	return level
 }
 
-// Error logs one or more unformatted messages to stderr if the logger is enabled
-func (logger *SimpleLogger) Error(messages ...interface{}) {
-	if logger.Enabled == true {
-		fmt.Fprintf(os.Stderr, "%s %s %s", bold(red(logger.Prefix)), red("Error:"), red(fmt.Sprintln(messages...)))
+// SetLevel sets the minimum level of the logger. A child logger no longer
+// follows its parent afterwards.
+func (logger *SimpleLogger) SetLevel(level Level) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	logger.Level = level
+	logger.inheritLevel = false
+}
+
+// IsEnabledFor returns whether messages with the given level would be logged
+func (logger *SimpleLogger) IsEnabledFor(level Level) bool {
+	return level >= logger.GetLevel() && logger.IsEnabled()
+}
+
+// AddHook adds a hook which receives all entries of the logger and its children
+func (logger *SimpleLogger) AddHook(hook Hook) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	logger.hooks = append(logger.hooks, hook)
+}
+
+// RemoveHook removes a hook which was added with AddHook
+func (logger *SimpleLogger) RemoveHook(hook Hook) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	for index, existingHook := range logger.hooks {
+		if existingHook == hook {
+			logger.hooks = append(logger.hooks[:index:index], logger.hooks[index+1:]...)
+			return
+		}
 	}
 }
 
-// Errorf logs one or more formatted messages to stderr if the logger is enabled
-func (logger *SimpleLogger) Errorf(format string, messages ...interface{}) {
-	if logger.Enabled == true {
-		fmt.Fprintf(os.Stderr, "%s %s %s\n", bold(red(logger.Prefix)), red("Error:"), red(fmt.Sprintf(format, messages...)))
+// allHooks returns the hooks of the logger and all its parents
+func (logger *SimpleLogger) allHooks() []Hook {
+	var hooks []Hook
+
+	for current := logger; current != nil; current = current.parent {
+		current.mutex.RLock()
+		hooks = append(hooks, current.hooks...)
+		current.mutex.RUnlock()
+	}
+
+	return hooks
+}
+
+// wants returns whether an entry with the level has to be created at all,
+// because it is logged or there are hooks
+func (logger *SimpleLogger) wants(level Level) bool {
+	return logger.IsEnabledFor(level) || len(logger.allHooks()) > 0
+}
+
+// output creates an entry and dispatches it. It must be called directly by the
+// exported logging methods, so the caller can be determined.
+func (logger *SimpleLogger) output(level Level, message *message, keyvals []interface{}) {
+	if !logger.wants(level) {
+		return
+	}
+
+	args := resolveLazy(message.args)
+
+	entry := &Entry{
+		Fields:  append(append([]Field{}, logger.Fields...), toFields(keyvals)...),
+		Level:   level,
+		Message: message.text(args),
+		Prefix:  logger.Prefix,
+		Time:    time.Now(),
+	}
+
+	if level >= LevelError {
+		entry.Errors = errorsFromArgs(args)
+	}
+
+	if logger.ReportCaller {
+		var pcs [1]uintptr
+		runtime.Callers(3, pcs[:])
+		entry.Caller = callerFromPC(pcs[0])
+	}
+
+	if logger.StackTraces && level >= LevelError {
+		entry.Stack = captureStack(2)
+	}
+
+	logger.dispatch(entry)
+}
+
+// dispatch masks the secrets of the entry, truncates it to MaxLength, passes it
+// to all hooks and writes it if the logger is enabled
+func (logger *SimpleLogger) dispatch(entry *Entry) {
+	redactor := logger.Redactor
+	if redactor == nil {
+		redactor = DefaultRedactor
+	}
+
+	redactor.Redact(entry)
+
+	if logger.MaxLength > 0 {
+		entry.Message = truncate(entry.Message, logger.MaxLength)
+		entry.Fields = truncateFields(entry.Fields, logger.MaxLength)
+	}
+
+	for _, hook := range logger.allHooks() {
+		hook.Fire(entry)
 	}
+
+	if logger.IsEnabledFor(entry.Level) {
+		logger.write(entry)
+	}
+}
+
+// namespaceDelta returns the time since the namespace logged the last time and
+// remembers the current time
+func namespaceDelta(namespace string, now time.Time) time.Duration {
+	lastLoggedMutex.Lock()
+	defer lastLoggedMutex.Unlock()
+
+	var delta time.Duration
+	if lastTime, found := lastLogged[namespace]; found {
+		delta = now.Sub(lastTime)
+	}
+
+	lastLogged[namespace] = now
+	return delta
+}
+
+// write passes the entry through the filters and writes it to the sinks
+func (logger *SimpleLogger) write(entry *Entry) {
+	for _, filter := range logger.Filters {
+		if !filter.Filter(entry, logger.emit) {
+			return
+		}
+	}
+
+	logger.emit(entry)
+}
+
+// emit writes the entry to the sinks or the writers of the logger, in the
+// background if the logger is asynchronous
+func (logger *SimpleLogger) emit(entry *Entry) {
+	if queue := logger.asyncQueue(); queue != nil && queue.enqueue(logger, entry) {
+		return
+	}
+
+	logger.emitNow(entry)
+}
+
+func (logger *SimpleLogger) emitNow(entry *Entry) {
+	entry.Delta = namespaceDelta(entry.Prefix, entry.Time)
+
+	if len(logger.Sinks) > 0 {
+		for _, sink := range logger.Sinks {
+			sink.WriteEntry(entry)
+		}
+		return
+	}
+
+	NewWriterSink(logger.Out, logger.ErrOut, logger.Formatter).WriteEntry(entry)
+}
+
+// Log logs one or more unformatted messages with debug level if the logger is enabled
+func (logger *SimpleLogger) Log(messages ...interface{}) {
+	logger.output(LevelDebug, unformatted(messages), nil)
+}
+
+// Logf logs one or more formatted messages with debug level if the logger is enabled
+func (logger *SimpleLogger) Logf(format string, messages ...interface{}) {
+	logger.output(LevelDebug, formatted(format, messages), nil)
+}
+
+// Trace logs a message and optional key/value pairs with trace level
+func (logger *SimpleLogger) Trace(message string, keyvals ...interface{}) {
+	logger.output(LevelTrace, plain(message), keyvals)
+}
+
+// Tracef logs one or more formatted messages with trace level
+func (logger *SimpleLogger) Tracef(format string, messages ...interface{}) {
+	logger.output(LevelTrace, formatted(format, messages), nil)
+}
+
+// Debug logs a message and optional key/value pairs with debug level
+func (logger *SimpleLogger) Debug(message string, keyvals ...interface{}) {
+	logger.output(LevelDebug, plain(message), keyvals)
+}
+
+// Debugf logs one or more formatted messages with debug level
+func (logger *SimpleLogger) Debugf(format string, messages ...interface{}) {
+	logger.output(LevelDebug, formatted(format, messages), nil)
+}
+
+// Info logs a message and optional key/value pairs with info level
+func (logger *SimpleLogger) Info(message string, keyvals ...interface{}) {
+	logger.output(LevelInfo, plain(message), keyvals)
+}
+
+// Infof logs one or more formatted messages with info level
+func (logger *SimpleLogger) Infof(format string, messages ...interface{}) {
+	logger.output(LevelInfo, formatted(format, messages), nil)
+}
+
+// Warn logs a message and optional key/value pairs with warn level to ErrOut
+func (logger *SimpleLogger) Warn(message string, keyvals ...interface{}) {
+	logger.output(LevelWarn, plain(message), keyvals)
+}
+
+// Warnf logs one or more formatted messages with warn level to ErrOut
+func (logger *SimpleLogger) Warnf(format string, messages ...interface{}) {
+	logger.output(LevelWarn, formatted(format, messages), nil)
+}
+
+// Error logs one or more unformatted messages with error level to ErrOut if the
+// logger is enabled. The causes of wrapped errors are logged below the message.
+func (logger *SimpleLogger) Error(messages ...interface{}) {
+	logger.output(LevelError, unformatted(messages), nil)
+}
+
+// Errorf logs one or more formatted messages with error level to ErrOut if the
+// logger is enabled. The causes of wrapped errors are logged below the message.
+func (logger *SimpleLogger) Errorf(format string, messages ...interface{}) {
+	logger.output(LevelError, formatted(format, messages), nil)
 }
@@ -0,0 +1,247 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Handler is a slog.Handler which writes the records with a SimpleLogger
+type Handler struct {
+	goas   []groupOrAttrs
+	logger *SimpleLogger
+}
+
+// groupOrAttrs is either a group name or a list of attributes added to a Handler
+type groupOrAttrs struct {
+	attrs []slog.Attr
+	group string
+}
+
+type slogSink struct {
+	handler slog.Handler
+}
+
+// NewHandler returns a new slog.Handler which writes the records with the logger,
+// including its prefix, fields, enabled state and level
+func NewHandler(logger *SimpleLogger) *Handler {
+	return &Handler{logger: logger}
+}
+
+// NewWithHandler returns a new instance of Logger which forwards all entries to
+// the slog.Handler. The prefix is added as the attribute "prefix".
+func NewWithHandler(prefix string, handler slog.Handler) *SimpleLogger {
+	return &SimpleLogger{
+		Enabled: true,
+		Level:   LevelTrace,
+		Prefix:  prefix,
+		Sinks:   []Sink{&slogSink{handler: handler}},
+	}
+}
+
+// FromSlogLevel converts a slog level into a Level
+func FromSlogLevel(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return LevelTrace
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	default:
+		return LevelError
+	}
+}
+
+// SlogLevel converts the level into a slog level. LevelTrace is mapped to
+// slog.LevelDebug-4.
+func (level Level) SlogLevel() slog.Level {
+	switch level {
+	case LevelTrace:
+		return slog.LevelDebug - 4
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// Enabled reports whether the logger is enabled for the level or has hooks
+func (handler *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return handler.logger.wants(FromSlogLevel(level))
+}
+
+// Handle writes the record and the fields of the context with the logger
+func (handler *Handler) Handle(ctx context.Context, record slog.Record) error {
+	level := FromSlogLevel(record.Level)
+
+	if !handler.logger.wants(level) {
+		return nil
+	}
+
+	var fields []Field
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, attr)
+		return true
+	})
+
+	for index := len(handler.goas) - 1; index >= 0; index-- {
+		goa := handler.goas[index]
+		if goa.group != "" {
+			if len(fields) > 0 {
+				fields = []Field{{Key: goa.group, Value: fields}}
+			}
+			continue
+		}
+
+		var attrFields []Field
+		for _, attr := range goa.attrs {
+			attrFields = appendAttr(attrFields, attr)
+		}
+		fields = append(attrFields, fields...)
+	}
+
+	entryTime := record.Time
+	if entryTime.IsZero() {
+		entryTime = time.Now()
+	}
+
+	entry := &Entry{
+		Fields:  append(append(append([]Field{}, handler.logger.Fields...), ContextFields(ctx)...), fields...),
+		Level:   level,
+		Message: record.Message,
+		Prefix:  handler.logger.Prefix,
+		Time:    entryTime,
+	}
+
+	if handler.logger.ReportCaller {
+		entry.Caller = callerFromPC(record.PC)
+	}
+
+	handler.logger.dispatch(entry)
+
+	return nil
+}
+
+// WithAttrs returns a new handler which adds the attributes to every record
+func (handler *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return handler
+	}
+
+	return handler.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup returns a new handler which puts all following attributes in the group
+func (handler *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return handler
+	}
+
+	return handler.withGroupOrAttrs(groupOrAttrs{group: name})
+}
+
+func (handler *Handler) withGroupOrAttrs(goa groupOrAttrs) *Handler {
+	goas := make([]groupOrAttrs, len(handler.goas), len(handler.goas)+1)
+	copy(goas, handler.goas)
+
+	return &Handler{
+		goas:   append(goas, goa),
+		logger: handler.logger,
+	}
+}
+
+// appendAttr converts the attribute into a field. Groups become fields with a
+// []Field value, groups without a key are inlined.
+func appendAttr(fields []Field, attr slog.Attr) []Field {
+	attr.Value = attr.Value.Resolve()
+
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if attr.Value.Kind() != slog.KindGroup {
+		return append(fields, Field{Key: attr.Key, Value: attr.Value.Any()})
+	}
+
+	var groupFields []Field
+	for _, groupAttr := range attr.Value.Group() {
+		groupFields = appendAttr(groupFields, groupAttr)
+	}
+
+	if len(groupFields) == 0 {
+		return fields
+	}
+
+	if attr.Key == "" {
+		return append(fields, groupFields...)
+	}
+
+	return append(fields, Field{Key: attr.Key, Value: groupFields})
+}
+
+// fieldToAttr converts the field into a slog attribute
+func fieldToAttr(field Field) slog.Attr {
+	groupFields, isGroup := field.Value.([]Field)
+
+	if !isGroup {
+		return slog.Any(field.Key, field.Value)
+	}
+
+	groupAttrs := make([]any, 0, len(groupFields))
+	for _, groupField := range groupFields {
+		groupAttrs = append(groupAttrs, fieldToAttr(groupField))
+	}
+
+	return slog.Group(field.Key, groupAttrs...)
+}
+
+// WriteEntry forwards the entry as a record to the slog.Handler
+func (sink *slogSink) WriteEntry(entry *Entry) error {
+	ctx := context.Background()
+	level := entry.Level.SlogLevel()
+
+	if !sink.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	var pc uintptr
+	if entry.Caller != nil {
+		pc = entry.Caller.pc
+	}
+
+	record := slog.NewRecord(entry.Time, level, entry.Message, pc)
+
+	if entry.Prefix != "" {
+		record.AddAttrs(slog.String("prefix", entry.Prefix))
+	}
+
+	for _, field := range entry.Fields {
+		record.AddAttrs(fieldToAttr(field))
+	}
+
+	return sink.handler.Handle(ctx, record)
+}
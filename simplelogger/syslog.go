@@ -0,0 +1,246 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Syslog severities, see RFC 5424 section 6.2.1
+const (
+	SeverityEmergency = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInformational
+	SeverityDebug
+)
+
+// FacilityUser is the syslog facility for user-level messages
+const FacilityUser = 1
+
+// syslogTimeFormat is RFC 3339 with at most six fractional digits, see RFC 5424
+// section 6.2.3
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// structuredDataID is the SD-ID for the fields of an entry
+const structuredDataID = "fields@32473"
+
+// localSyslogAddresses are the usual unix sockets of the local syslog daemon
+var localSyslogAddresses = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogSink is a sink which sends the entries in the RFC 5424 format to a
+// syslog server. The prefix of an entry is used as APP-NAME, its fields as
+// structured data.
+type SyslogSink struct {
+	Address  string
+	Facility int
+	Hostname string
+	Network  string
+
+	conn  net.Conn
+	mutex sync.Mutex
+}
+
+// NewSyslogSink returns a new instance of SyslogSink which is connected to the
+// address. The network is "unixgram", "unix", "udp" or "tcp". If network and
+// address are empty, the local syslog daemon is used.
+func NewSyslogSink(network string, address string) (*SyslogSink, error) {
+	hostname, hostnameError := os.Hostname()
+	if hostnameError != nil {
+		hostname = "-"
+	}
+
+	sink := &SyslogSink{
+		Address:  address,
+		Facility: FacilityUser,
+		Hostname: hostname,
+		Network:  network,
+	}
+
+	if connectError := sink.connect(); connectError != nil {
+		return nil, connectError
+	}
+
+	return sink, nil
+}
+
+// SyslogSeverity returns the syslog severity for the level
+func (level Level) SyslogSeverity() int {
+	switch level {
+	case LevelTrace, LevelDebug:
+		return SeverityDebug
+	case LevelInfo:
+		return SeverityInformational
+	case LevelWarn:
+		return SeverityWarning
+	default:
+		return SeverityError
+	}
+}
+
+func (sink *SyslogSink) connect() error {
+	if sink.Network != "" || sink.Address != "" {
+		conn, dialError := net.Dial(sink.Network, sink.Address)
+		if dialError != nil {
+			return dialError
+		}
+		sink.conn = conn
+		return nil
+	}
+
+	for _, address := range localSyslogAddresses {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, dialError := net.Dial(network, address); dialError == nil {
+				sink.conn = conn
+				return nil
+			}
+		}
+	}
+
+	return errors.New("Could not connect to the local syslog daemon")
+}
+
+// WriteEntry sends the entry to the syslog server. It reconnects once if the
+// connection was lost.
+func (sink *SyslogSink) WriteEntry(entry *Entry) error {
+	message := sink.format(entry)
+
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if sink.conn != nil {
+		if writeError := sink.write(message); writeError == nil {
+			return nil
+		}
+		sink.conn.Close()
+		sink.conn = nil
+	}
+
+	if connectError := sink.connect(); connectError != nil {
+		return connectError
+	}
+
+	return sink.write(message)
+}
+
+// write sends the message over the connection. Messages over stream sockets
+// ("tcp" and "unix") are framed with octet counting (RFC 6587), so that the
+// server can split them.
+func (sink *SyslogSink) write(message []byte) error {
+	switch sink.conn.RemoteAddr().Network() {
+	case "tcp", "tcp4", "tcp6", "unix":
+		message = append([]byte(fmt.Sprintf("%d ", len(message))), message...)
+	}
+
+	_, writeError := sink.conn.Write(message)
+	return writeError
+}
+
+// Close closes the connection to the syslog server
+func (sink *SyslogSink) Close() error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if sink.conn == nil {
+		return nil
+	}
+
+	closeError := sink.conn.Close()
+	sink.conn = nil
+
+	return closeError
+}
+
+// format returns the entry as RFC 5424 message
+func (sink *SyslogSink) format(entry *Entry) []byte {
+	var buffer bytes.Buffer
+
+	priority := sink.Facility*8 + entry.Level.SyslogSeverity()
+	fmt.Fprintf(
+		&buffer,
+		"<%d>1 %s %s %s %d - ",
+		priority,
+		entry.Time.Format(syslogTimeFormat),
+		syslogHeaderField(sink.Hostname, 255),
+		syslogHeaderField(entry.Prefix, 48),
+		os.Getpid(),
+	)
+
+	fields := flattenFields("", entry.Fields)
+
+	if len(fields) == 0 {
+		buffer.WriteByte('-')
+	} else {
+		buffer.WriteString("[" + structuredDataID)
+		for _, field := range fields {
+			fmt.Fprintf(&buffer, " %s=\"%s\"", syslogParamName(field.Key), syslogParamValue(stringValue(field.Value)))
+		}
+		buffer.WriteByte(']')
+	}
+
+	buffer.WriteByte(' ')
+	buffer.WriteString(entry.Message)
+
+	return buffer.Bytes()
+}
+
+// syslogHeaderField returns the value with only printable US-ASCII characters
+// and at most maxLength characters, or "-" if it is empty
+func syslogHeaderField(value string, maxLength int) string {
+	cleanValue := strings.Map(func(character rune) rune {
+		if character < 33 || character > 126 {
+			return -1
+		}
+		return character
+	}, value)
+
+	if len(cleanValue) > maxLength {
+		cleanValue = cleanValue[:maxLength]
+	}
+
+	if cleanValue == "" {
+		return "-"
+	}
+
+	return cleanValue
+}
+
+func syslogParamName(key string) string {
+	name := strings.Map(func(character rune) rune {
+		if character < 33 || character > 126 || character == '=' || character == ']' || character == '"' {
+			return '_'
+		}
+		return character
+	}, key)
+
+	return syslogHeaderField(name, 32)
+}
+
+func syslogParamValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,197 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+	"github.com/ffflorian/go-tools/simplelogger/loggertest"
+)
+
+// listenUnixgram returns a datagram socket in a temporary directory. The
+// directory is kept short, since unix socket paths are limited to ~100 bytes.
+func listenUnixgram(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+
+	socketDir, tempError := os.MkdirTemp("", "simplelogger")
+	if tempError != nil {
+		t.Fatal(tempError)
+	}
+	t.Cleanup(func() { os.RemoveAll(socketDir) })
+
+	address := filepath.Join(socketDir, "socket")
+	conn, listenError := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: address, Net: "unixgram"})
+	if listenError != nil {
+		t.Skip("unix datagram sockets are not supported:", listenError)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, address
+}
+
+func readDatagram(t *testing.T, conn *net.UnixConn) []byte {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	buffer := make([]byte, 65536)
+	length, readError := conn.Read(buffer)
+	if readError != nil {
+		t.Fatal(readError)
+	}
+
+	return buffer[:length]
+}
+
+func newSinkLogger(sink simplelogger.Sink) *simplelogger.SimpleLogger {
+	logger := simplelogger.New("my-app", true, false)
+	logger.Sinks = []simplelogger.Sink{sink}
+	return logger
+}
+
+func TestSyslogSinkUnixgram(t *testing.T) {
+	conn, address := listenUnixgram(t)
+
+	sink, sinkError := simplelogger.NewSyslogSink("unixgram", address)
+	if sinkError != nil {
+		t.Fatal(sinkError)
+	}
+	defer sink.Close()
+	sink.Hostname = "host"
+
+	logger := newSinkLogger(sink)
+	recorder := loggertest.Record(t, logger)
+
+	logger.Warn("disk almost full", "disk", "/dev/sda", "quote", `say "hi"]`)
+
+	message := string(readDatagram(t, conn))
+	pattern := regexp.MustCompile(`^<12>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}(Z|[+-]\d\d:\d\d) host my-app \d+ - ` +
+		regexp.QuoteMeta(`[fields@32473 disk="/dev/sda" quote="say \"hi\"\]"] disk almost full`) + `$`)
+
+	if !pattern.MatchString(message) {
+		t.Errorf("unexpected syslog message %q", message)
+	}
+
+	if !recorder.Contains("disk almost full") {
+		t.Error("hooks did not receive the entry")
+	}
+}
+
+func TestSyslogSinkSeverities(t *testing.T) {
+	conn, address := listenUnixgram(t)
+
+	sink, sinkError := simplelogger.NewSyslogSink("unixgram", address)
+	if sinkError != nil {
+		t.Fatal(sinkError)
+	}
+	defer sink.Close()
+
+	logger := newSinkLogger(sink)
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Error("error")
+
+	for _, priority := range []string{"<15>", "<14>", "<11>"} {
+		if message := string(readDatagram(t, conn)); !strings.HasPrefix(message, priority+"1 ") {
+			t.Errorf("message %q doesn't start with %s", message, priority)
+		}
+	}
+}
+
+// readFramed reads a message which is framed with octet counting
+func readFramed(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+
+	lengthText, readError := reader.ReadString(' ')
+	if readError != nil {
+		t.Fatal(readError)
+	}
+
+	length, parseError := strconv.Atoi(strings.TrimSpace(lengthText))
+	if parseError != nil {
+		t.Fatal(parseError)
+	}
+
+	message := make([]byte, length)
+	if _, readError := io.ReadFull(reader, message); readError != nil {
+		t.Fatal(readError)
+	}
+
+	return string(message)
+}
+
+func TestSyslogSinkStream(t *testing.T) {
+	socketDir, tempError := os.MkdirTemp("", "simplelogger")
+	if tempError != nil {
+		t.Fatal(tempError)
+	}
+	defer os.RemoveAll(socketDir)
+
+	testCases := []struct {
+		address string
+		network string
+	}{
+		{address: "127.0.0.1:0", network: "tcp"},
+		{address: filepath.Join(socketDir, "socket"), network: "unix"},
+	}
+
+	for _, testCase := range testCases {
+		listener, listenError := net.Listen(testCase.network, testCase.address)
+		if listenError != nil {
+			t.Logf("skipping %s: %s", testCase.network, listenError)
+			continue
+		}
+		defer listener.Close()
+
+		sink, sinkError := simplelogger.NewSyslogSink(testCase.network, listener.Addr().String())
+		if sinkError != nil {
+			t.Fatal(sinkError)
+		}
+		defer sink.Close()
+
+		conn, acceptError := listener.Accept()
+		if acceptError != nil {
+			t.Fatal(acceptError)
+		}
+		defer conn.Close()
+
+		logger := newSinkLogger(sink)
+		logger.Info("first message")
+		logger.Warn("second\nmessage")
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		reader := bufio.NewReader(conn)
+
+		if message := readFramed(t, reader); !strings.HasPrefix(message, "<14>1 ") || !strings.HasSuffix(message, " - first message") {
+			t.Errorf("%s: unexpected octet-counted message %q", testCase.network, message)
+		}
+		if message := readFramed(t, reader); !strings.HasPrefix(message, "<12>1 ") || !strings.HasSuffix(message, " - second\nmessage") {
+			t.Errorf("%s: unexpected octet-counted message %q", testCase.network, message)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPrettyDepth is the maximum nesting depth of pretty-printed values
+const maxPrettyDepth = 10
+
+// message is the text of a log call, which is only built if the entry is
+// needed. Without a format, the arguments are printed like with fmt.Sprintln,
+// but structs, maps and slices are pretty-printed.
+type message struct {
+	args      []interface{}
+	format    string
+	hasFormat bool
+}
+
+func unformatted(args []interface{}) *message {
+	return &message{args: args}
+}
+
+func formatted(format string, args []interface{}) *message {
+	return &message{args: args, format: format, hasFormat: true}
+}
+
+func plain(text string) *message {
+	return &message{args: []interface{}{text}}
+}
+
+func (message *message) text(args []interface{}) string {
+	if message.hasFormat {
+		return fmt.Sprintf(message.format, args...)
+	}
+
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		parts = append(parts, prettyValue(arg))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// resolveLazy calls all arguments of the type func() interface{} (or
+// func() string) and returns their results instead
+func resolveLazy(args []interface{}) []interface{} {
+	var resolvedArgs []interface{}
+
+	for index, arg := range args {
+		var resolvedArg interface{}
+
+		switch lazyArg := arg.(type) {
+		case func() interface{}:
+			resolvedArg = lazyArg()
+		case func() string:
+			resolvedArg = lazyArg()
+		default:
+			if resolvedArgs != nil {
+				resolvedArgs = append(resolvedArgs, arg)
+			}
+			continue
+		}
+
+		if resolvedArgs == nil {
+			resolvedArgs = append(make([]interface{}, 0, len(args)), args[:index]...)
+		}
+		resolvedArgs = append(resolvedArgs, resolvedArg)
+	}
+
+	if resolvedArgs == nil {
+		return args
+	}
+
+	return resolvedArgs
+}
+
+// prettyValue returns structs, maps, slices and arrays (and pointers to them)
+// in an indented multi-line form and all other values like fmt.Sprint
+func prettyValue(value interface{}) string {
+	switch value.(type) {
+	case nil, string, error, fmt.Stringer, []byte:
+		return fmt.Sprint(value)
+	}
+
+	reflectValue := reflect.ValueOf(value)
+	if !isComposite(reflectValue) {
+		return fmt.Sprint(value)
+	}
+
+	var buffer bytes.Buffer
+	writePretty(&buffer, reflectValue, 0)
+	return buffer.String()
+}
+
+func isComposite(value reflect.Value) bool {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return false
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+func writePretty(buffer *bytes.Buffer, value reflect.Value, depth int) {
+	indentation := strings.Repeat("  ", depth+1)
+	closingIndentation := strings.Repeat("  ", depth)
+
+	if depth > maxPrettyDepth {
+		buffer.WriteString("…")
+		return
+	}
+
+	if value.IsValid() && value.CanInterface() {
+		switch typedValue := value.Interface().(type) {
+		case error:
+			if value.Kind() != reflect.Pointer || !value.IsNil() {
+				buffer.WriteString(errorText(typedValue))
+				return
+			}
+		case fmt.Stringer:
+			if value.Kind() != reflect.Pointer || !value.IsNil() {
+				buffer.WriteString(fmt.Sprint(typedValue))
+				return
+			}
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Invalid:
+		buffer.WriteString("<nil>")
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			buffer.WriteString("<nil>")
+			return
+		}
+		if value.Kind() == reflect.Pointer {
+			buffer.WriteByte('&')
+		}
+		writePretty(buffer, value.Elem(), depth)
+	case reflect.Struct:
+		buffer.WriteString(value.Type().Name() + "{")
+		if value.NumField() == 0 {
+			buffer.WriteByte('}')
+			return
+		}
+		buffer.WriteByte('\n')
+		for index := 0; index < value.NumField(); index++ {
+			buffer.WriteString(indentation + value.Type().Field(index).Name + ": ")
+			writePretty(buffer, value.Field(index), depth+1)
+			buffer.WriteString(",\n")
+		}
+		buffer.WriteString(closingIndentation + "}")
+	case reflect.Map:
+		if value.Len() == 0 {
+			buffer.WriteString("{}")
+			return
+		}
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		buffer.WriteString("{\n")
+		for _, key := range keys {
+			buffer.WriteString(indentation)
+			writePretty(buffer, key, depth+1)
+			buffer.WriteString(": ")
+			writePretty(buffer, value.MapIndex(key), depth+1)
+			buffer.WriteString(",\n")
+		}
+		buffer.WriteString(closingIndentation + "}")
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() || value.Len() == 0 {
+			buffer.WriteString("[]")
+			return
+		}
+		buffer.WriteString("[\n")
+		for index := 0; index < value.Len(); index++ {
+			buffer.WriteString(indentation)
+			writePretty(buffer, value.Index(index), depth+1)
+			buffer.WriteString(",\n")
+		}
+		buffer.WriteString(closingIndentation + "]")
+	case reflect.String:
+		buffer.WriteString(strconv.Quote(value.String()))
+	default:
+		fmt.Fprint(buffer, value)
+	}
+}
+
+// truncate shortens the text to maxLength bytes (if maxLength is positive) and
+// appends how many bytes were omitted
+func truncate(text string, maxLength int) string {
+	if maxLength <= 0 || len(text) <= maxLength {
+		return text
+	}
+
+	cut := maxLength
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+
+	return fmt.Sprintf("%s…(%d bytes omitted)", text[:cut], len(text)-cut)
+}
+
+// truncateFields shortens all values of the fields. Other values than strings
+// are kept as they are if their text fits, otherwise their truncated text is used.
+func truncateFields(fields []Field, maxLength int) []Field {
+	truncatedFields := make([]Field, 0, len(fields))
+
+	for _, field := range fields {
+		switch value := field.Value.(type) {
+		case string:
+			field.Value = truncate(value, maxLength)
+		case []Field:
+			field.Value = truncateFields(value, maxLength)
+		default:
+			if text := stringValue(value); len(text) > maxLength {
+				field.Value = truncate(text, maxLength)
+			}
+		}
+		truncatedFields = append(truncatedFields, field)
+	}
+
+	return truncatedFields
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ffflorian/go-tools/simplelogger"
+)
+
+type pullRequest struct {
+	Title string
+	URL   string
+}
+
+func TestMaxLength(t *testing.T) {
+	logger, recorder := newContextLogger(t)
+	logger.MaxLength = 20
+
+	pullRequests := []pullRequest{{Title: "First", URL: "https://github.com/user/repo/pull/1"}}
+	labels := map[string]int{"bug": 1, "documentation": 2, "enhancement": 3}
+
+	logger.Info(strings.Repeat("m", 30), "text", strings.Repeat("t", 25), "prs", pullRequests, "labels", labels,
+		"count", 42, "short", pullRequest{Title: "x"}, simplelogger.Group("request", "body", strings.Repeat("b", 21)))
+
+	entry := recorder.All()[0]
+
+	if entry.Message != strings.Repeat("m", 20)+"…(10 bytes omitted)" {
+		t.Errorf("unexpected message %q", entry.Message)
+	}
+
+	expected := map[string]string{
+		"text":   strings.Repeat("t", 20) + "…(5 bytes omitted)",
+		"prs":    "[{First https://gith…",
+		"labels": "map[bug:1 documentat…",
+	}
+
+	for _, field := range entry.Fields {
+		switch field.Key {
+		case "text", "prs", "labels":
+			if text, isString := field.Value.(string); !isString || !strings.HasPrefix(text, expected[field.Key]) || !strings.HasSuffix(text, "bytes omitted)") {
+				t.Errorf("field %s was not truncated: %#v", field.Key, field.Value)
+			}
+		case "count":
+			if field.Value != 42 {
+				t.Errorf("short field %s was changed: %#v", field.Key, field.Value)
+			}
+		case "short":
+			if _, isStruct := field.Value.(pullRequest); !isStruct {
+				t.Errorf("short field %s was changed: %#v", field.Key, field.Value)
+			}
+		case "request":
+			group := field.Value.([]simplelogger.Field)
+			if group[0].Value != strings.Repeat("b", 20)+"…(1 bytes omitted)" {
+				t.Errorf("grouped field was not truncated: %#v", group[0].Value)
+			}
+		}
+	}
+}
+
+func TestMaxLengthRuneBoundary(t *testing.T) {
+	logger, recorder := newContextLogger(t)
+	logger.MaxLength = 4
+
+	logger.Info("aaaé", "emoji", "a🙂b")
+
+	entry := recorder.All()[0]
+	if entry.Message != "aaa…(2 bytes omitted)" {
+		t.Errorf("unexpected message %q", entry.Message)
+	}
+	if entry.Fields[0].Value != "a…(5 bytes omitted)" {
+		t.Errorf("unexpected field %q", entry.Fields[0].Value)
+	}
+}
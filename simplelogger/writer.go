@@ -0,0 +1,124 @@
+/*
+Copyright © 2019 Florian Imdahl <git@ffflorian.de>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package simplelogger
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// outputMutex serializes all writes of WriterSinks, so lines of different
+// goroutines and loggers don't interleave
+var outputMutex sync.Mutex
+
+// WriterSink is a sink which formats the entries and writes them to io.Writers.
+// Entries with level warn and above go to ErrOut, all others to Out.
+// Both default to stderr. Colours are detected for every writer, also for all
+// writers of a Tee.
+type WriterSink struct {
+	ErrOut    io.Writer
+	Formatter Formatter
+	Out       io.Writer
+}
+
+// teeWriter duplicates its writes to all writers, like io.MultiWriter
+type teeWriter struct {
+	writers []io.Writer
+}
+
+// NewWriterSink returns a new instance of WriterSink
+func NewWriterSink(out io.Writer, errOut io.Writer, formatter Formatter) *WriterSink {
+	return &WriterSink{
+		ErrOut:    errOut,
+		Formatter: formatter,
+		Out:       out,
+	}
+}
+
+// Tee returns a writer which duplicates its writes to all the writers, e.g. to
+// write to the terminal and a file at once
+func Tee(writers ...io.Writer) io.Writer {
+	var allWriters []io.Writer
+
+	for _, writer := range writers {
+		if tee, isTee := writer.(*teeWriter); isTee {
+			allWriters = append(allWriters, tee.writers...)
+		} else if writer != nil {
+			allWriters = append(allWriters, writer)
+		}
+	}
+
+	return &teeWriter{writers: allWriters}
+}
+
+func (tee *teeWriter) Write(buffer []byte) (int, error) {
+	for _, writer := range tee.writers {
+		written, writeError := writer.Write(buffer)
+		if writeError != nil {
+			return written, writeError
+		}
+		if written != len(buffer) {
+			return written, io.ErrShortWrite
+		}
+	}
+
+	return len(buffer), nil
+}
+
+// WriteEntry formats the entry and writes it to Out or ErrOut
+func (sink *WriterSink) WriteEntry(entry *Entry) error {
+	formatter := sink.Formatter
+	if formatter == nil {
+		formatter = &TextFormatter{}
+	}
+
+	writer := sink.Out
+	if entry.Level >= LevelWarn {
+		writer = sink.ErrOut
+	}
+	if writer == nil {
+		writer = os.Stderr
+	}
+
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	tee, isTee := writer.(*teeWriter)
+	if !isTee {
+		return sink.writeFormatted(writer, formatter, entry)
+	}
+
+	for _, teeWriter := range tee.writers {
+		if writeError := sink.writeFormatted(teeWriter, formatter, entry); writeError != nil {
+			return writeError
+		}
+	}
+
+	return nil
+}
+
+// writeFormatted formats the entry with the colour level of the writer and
+// writes it
+func (sink *WriterSink) writeFormatted(writer io.Writer, formatter Formatter, entry *Entry) error {
+	coloredEntry := *entry
+	coloredEntry.Color = DetectColor(writer)
+
+	_, writeError := writer.Write(formatter.Format(&coloredEntry))
+	return writeError
+}